@@ -24,7 +24,7 @@ func DisplayAppError(w http.ResponseWriter, handlerError error, message string,
 		HTTPStatus: code,
 	}
 	//log.Printf("AppError]: %s\n", handlerError)
-	Error.Printf("AppError]: %s\n", handlerError)
+	Logger.Error("AppError", "error", handlerError)
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(code)
 	if j, err := json.Marshal(errorResource{Data: errObj}); err == nil {
@@ -4,11 +4,14 @@ import (
 	"context"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+
+	"github.com/shijuvar/gokit/log"
+	"github.com/shijuvar/gokit/server"
 
 	util "github.com/shijuvar/gokit/examples/bookmark-api/apputil"
 	"github.com/shijuvar/gokit/examples/bookmark-api/bootstrapper"
+	"github.com/shijuvar/gokit/examples/bookmark-api/linkcheck"
+	"github.com/shijuvar/gokit/examples/bookmark-api/mongodb"
 	"github.com/shijuvar/gokit/examples/bookmark-api/router"
 )
 
@@ -17,35 +20,58 @@ func main() {
 
 	// Calls startup logic
 	bootstrapper.StartUp()
-	// Get the mux router object
-	router := router.InitRoutes()
+	// Get the mux router object with the MongoDB stores
+	db := bootstrapper.Database()
+	bookmarks := mongodb.NewBookmarkStore(db)
+	router := router.InitRoutes(bookmarks, mongodb.NewUserStore(db))
+
+	// Liveness and readiness probes; ready when MongoDB answers a ping
+	health := server.NewHealth()
+	health.AddCheck("mongodb", bootstrapper.Ping)
+	router.Handle("/healthz", health.LivenessHandler()).Methods("GET")
+	router.Handle("/readyz", health.ReadinessHandler()).Methods("GET")
 
 	// Create the Server
-	server := &http.Server{
+	runner := server.NewRunner(&http.Server{
 		Addr:     bootstrapper.AppConfig.Server,
 		Handler:  router,
-		ErrorLog: util.Error,
+		ErrorLog: util.Logger.StdLogger(util.ERROR),
+	})
+	runner.Health = health
+	runner.Logger = util.Logger
+	if d := bootstrapper.AppConfig.ShutdownTimeout; d > 0 {
+		runner.DrainTimeout = d
 	}
-
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, os.Kill, syscall.SIGTERM)
-
-	// Running the HTTP server
-	go func() {
-		server.ListenAndServe()
-	}()
-
-	interruptSignal := <-interrupt
-	switch interruptSignal {
-	case os.Kill:
-		util.Error.Print("Got SIGKILL...")
-	case os.Interrupt:
-		util.Error.Print("Got SIGINT...")
-	case syscall.SIGTERM:
-		util.Error.Print("Got SIGTERM...")
+	// Background link checker, stopped before MongoDB is disconnected
+	if interval := bootstrapper.AppConfig.LinkCheckInterval; interval > 0 {
+		checker := linkcheck.NewChecker(bookmarks, linkcheck.Config{
+			Interval:    interval,
+			Concurrency: bootstrapper.AppConfig.LinkCheckConcurrency,
+			HostDelay:   bootstrapper.AppConfig.LinkCheckHostDelay,
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			checker.Run(ctx)
+		}()
+		runner.OnShutdown("linkcheck", func(shutdownCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-shutdownCtx.Done():
+				return shutdownCtx.Err()
+			}
+		})
 	}
+	// Cleanup hooks run in order once in-flight requests are drained
+	runner.OnShutdown("mongodb", bootstrapper.CloseSession)
+	runner.OnShutdown("logs", func(ctx context.Context) error {
+		return log.Sync()
+	})
 
-	util.Info.Print("The service is shutting down...")
-	server.Shutdown(context.Background())
-	util.Info.Print("Shut down is done")
+	if err := runner.Run(); err != nil {
+		os.Exit(1)
+	}
 }
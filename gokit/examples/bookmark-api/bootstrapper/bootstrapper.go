@@ -2,6 +2,7 @@ package bootstrapper
 
 import (
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -23,6 +24,13 @@ func StartUp() {
 type configuration struct {
 	Server, MongoDBHost, DBUser, DBPwd, Database string
 	LogLevel                                     int
+	// ShutdownTimeout is how long in-flight requests may take on shutdown
+	ShutdownTimeout time.Duration
+	// LinkCheckInterval is how often bookmark links are checked again;
+	// 0 disables the link checker
+	LinkCheckInterval    time.Duration
+	LinkCheckConcurrency int
+	LinkCheckHostDelay   time.Duration
 }
 
 // AppConfig holds the configuration values from config.json file
@@ -44,5 +52,9 @@ func init() {
 	AppConfig.DBPwd = viper.GetString("development.DBPwd")
 	AppConfig.Database = viper.GetString("development.Database")
 	AppConfig.LogLevel = viper.GetInt("development.LogLevel")
+	AppConfig.ShutdownTimeout = viper.GetDuration("development.ShutdownTimeout")
+	AppConfig.LinkCheckInterval = viper.GetDuration("development.LinkCheckInterval")
+	AppConfig.LinkCheckConcurrency = viper.GetInt("development.LinkCheckConcurrency")
+	AppConfig.LinkCheckHostDelay = viper.GetDuration("development.LinkCheckHostDelay")
 
 }
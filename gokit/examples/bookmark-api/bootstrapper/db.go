@@ -1,35 +1,29 @@
 package bootstrapper
 
 import (
+	"context"
 	"log"
 	"time"
 
-	"gopkg.in/mgo.v2"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/mongodb"
 )
 
-var session *mgo.Session
-
-// GetSession returns a MongoDB Session
-func GetSession() *mgo.Session {
-	if session == nil {
-		var err error
-		session, err = mgo.DialWithInfo(&mgo.DialInfo{
-			Addrs:    []string{AppConfig.MongoDBHost},
-			Username: AppConfig.DBUser,
-			Password: AppConfig.DBPwd,
-			Timeout:  60 * time.Second,
-		})
-		if err != nil {
-			log.Fatalf("[GetSession]: %s\n", err)
-		}
-	}
-	return session
+var client *mongo.Client
+
+// Database returns the MongoDB database of the application
+func Database() *mongo.Database {
+	return client.Database(AppConfig.Database)
 }
+
 func createDBSession() {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
 	var err error
-	session, err = mgo.DialWithInfo(&mgo.DialInfo{
-		Addrs:    []string{AppConfig.MongoDBHost},
-		Username: AppConfig.DBUser,
+	client, err = mongodb.Connect(ctx, mongodb.Config{
+		Host:     AppConfig.MongoDBHost,
+		User:     AppConfig.DBUser,
 		Password: AppConfig.DBPwd,
 		Timeout:  60 * time.Second,
 	})
@@ -38,22 +32,28 @@ func createDBSession() {
 	}
 }
 
+// Ping checks that MongoDB is reachable, used for readiness probes
+func Ping(ctx context.Context) error {
+	return client.Ping(ctx, nil)
+}
+
+// CloseSession disconnects from MongoDB
+func CloseSession(ctx context.Context) error {
+	if client == nil {
+		return nil
+	}
+	return client.Disconnect(ctx)
+}
+
 // Add indexes into MongoDB
 func addIndexes() {
-	var err error
-	userIndex := mgo.Index{
-		Key:        []string{"email"},
-		Unique:     true,
-		Background: true,
-		Sparse:     true,
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	if err := mongodb.EnsureIndexes(ctx, Database()); err != nil {
+		log.Fatalf("[addIndexes]: %s\n", err)
 	}
-	// Add indexes into MongoDB
-	session := GetSession().Copy()
-	defer session.Close()
-	userCol := session.DB(AppConfig.Database).C("users")
-
-	err = userCol.EnsureIndex(userIndex)
-	if err != nil {
+	// Text index for GET /bookmarks/search
+	if err := mongodb.EnsureSearchIndex(ctx, Database()); err != nil {
 		log.Fatalf("[addIndexes]: %s\n", err)
 	}
 }
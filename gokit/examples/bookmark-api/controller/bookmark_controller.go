@@ -2,19 +2,25 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
-	"gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
 
 	utils "github.com/shijuvar/gokit/examples/bookmark-api/apputil"
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
 	"github.com/shijuvar/gokit/examples/bookmark-api/store"
 )
 
+// BookmarkController handles the "/bookmarks" resource
+type BookmarkController struct {
+	Store store.BookmarkStore // injected, so it can be replaced in tests
+}
+
 // CreateBookmark insert a new Bookmark.
 // Handler for HTTP Post - "/bookmarks
-func CreateBookmark(w http.ResponseWriter, r *http.Request) {
+func (c BookmarkController) CreateBookmark(w http.ResponseWriter, r *http.Request) {
 	var dataResource BookmarkResource
 	// Decode the incoming Bookmark json
 	err := json.NewDecoder(r.Body).Decode(&dataResource)
@@ -28,21 +34,22 @@ func CreateBookmark(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	bookmark := &dataResource.Data
-	// Creates a new DatStore value to working with MongoDB store.
-	dataStore := NewDataStore()
-	// Add to the mgo.Session.Close()
-	defer dataStore.Close()
-	// Get the mgo.Collection for "bookmarks"
-	col := dataStore.Collection("bookmarks")
-	// Creates an instance of BookmarkStore
-	bookmarkStore := store.BookmarkStore{C: col}
-	// Takes user name from Context
-	user := r.Context().Value("user")
-	if user != nil {
-		bookmark.CreatedBy = user.(string)
+	// The owner is the user of the access token
+	bookmark.CreatedBy = currentUser(r)
+	// Health is only set by the link checker
+	bookmark.Health = nil
+	// The store sets the creation time
+	bookmark.CreatedOn = time.Time{}
+	if bookmark.Visibility == "" {
+		bookmark.Visibility = model.Private
+	}
+	if err := validateSharing(bookmark.Visibility); err != nil {
+		utils.DisplayAppError(w, err, "Invalid Bookmark data", http.StatusBadRequest)
+		return
 	}
+	bookmark.SharedWith = sharedWith(bookmark.Visibility, bookmark.SharedWith, bookmark.CreatedBy)
 	// Insert a bookmark document
-	err = bookmarkStore.Create(bookmark)
+	err = c.Store.Create(r.Context(), bookmark)
 	if err != nil {
 		utils.DisplayAppError(
 			w,
@@ -71,15 +78,23 @@ func CreateBookmark(w http.ResponseWriter, r *http.Request) {
 
 }
 
-// GetBookmarks returns all Bookmark documents
+// GetBookmarks returns a page of Bookmark documents
 // Handler for HTTP Get - "/Bookmarks"
-func GetBookmarks(w http.ResponseWriter, r *http.Request) {
-	dataStore := NewDataStore()
-	defer dataStore.Close()
-	col := dataStore.Collection("bookmarks")
-	bookmarkStore := store.BookmarkStore{C: col}
-	bookmarks := bookmarkStore.GetAll()
-	j, err := json.Marshal(BookmarksResource{Data: bookmarks})
+func (c BookmarkController) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	c.listBookmarks(w, r, func(q *store.BookmarkQuery) {})
+}
+
+// GetBookmarkByID returns a single bookmark document by id
+// Handler for HTTP Get - "/Bookmarks/{id}"
+func (c BookmarkController) GetBookmarkByID(w http.ResponseWriter, r *http.Request) {
+	// Get id from the incoming url
+	vars := mux.Vars(r)
+	bookmark, err := c.authorize(r, vars["id"], false)
+	if err != nil {
+		displayStoreError(w, err)
+		return
+	}
+	j, err := json.Marshal(bookmark)
 	if err != nil {
 		utils.DisplayAppError(
 			w,
@@ -89,40 +104,46 @@ func GetBookmarks(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(j)
 }
 
-// GetBookmarkByID returns a single bookmark document by id
-// Handler for HTTP Get - "/Bookmarks/{id}"
-func GetBookmarkByID(w http.ResponseWriter, r *http.Request) {
+// GetBookmarksByUser returns a page of Bookmarks created by a User
+// Handler for HTTP Get - "/Bookmarks/users/{id}"
+func (c BookmarkController) GetBookmarksByUser(w http.ResponseWriter, r *http.Request) {
 	// Get id from the incoming url
 	vars := mux.Vars(r)
-	id := vars["id"]
+	c.listBookmarks(w, r, func(q *store.BookmarkQuery) {
+		q.CreatedBy = vars["id"]
+	})
+}
 
-	dataStore := NewDataStore()
-	defer dataStore.Close()
-	col := dataStore.Collection("bookmarks")
-	bookmarkStore := store.BookmarkStore{C: col}
+// BrokenBookmarks returns a page of the user's Bookmarks whose link
+// failed its last check
+// Handler for HTTP Get - "/Bookmarks/broken"
+func (c BookmarkController) BrokenBookmarks(w http.ResponseWriter, r *http.Request) {
+	c.listBookmarks(w, r, func(q *store.BookmarkQuery) {
+		q.CreatedBy = currentUser(r)
+		q.Broken = true
+	})
+}
 
-	bookmark, err := bookmarkStore.GetByID(id)
+// SearchBookmarks runs a full-text search on the Bookmarks of the user
+// Handler for HTTP Get - "/Bookmarks/search?q="
+func (c BookmarkController) SearchBookmarks(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r.URL.Query(), "limit")
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			w.WriteHeader(http.StatusNoContent)
-
-		} else {
-			utils.DisplayAppError(
-				w,
-				err,
-				"An unexpected error has occurred",
-				500,
-			)
-
-		}
+		utils.DisplayAppError(w, err, "Invalid query parameters", http.StatusBadRequest)
 		return
 	}
-	j, err := json.Marshal(bookmark)
+	query := store.SearchQuery{Text: r.URL.Query().Get("q"), Limit: limit}
+	query.CreatedBy = currentUser(r)
+	if query, err = query.Normalize(); err != nil {
+		utils.DisplayAppError(w, err, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	results, err := c.Store.Search(r.Context(), query)
 	if err != nil {
 		utils.DisplayAppError(
 			w,
@@ -132,23 +153,15 @@ func GetBookmarkByID(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(j)
-}
-
-// GetBookmarksByUser returns all Bookmarks created by a User
-// Handler for HTTP Get - "/Bookmarks/users/{id}"
-func GetBookmarksByUser(w http.ResponseWriter, r *http.Request) {
-	// Get id from the incoming url
-	vars := mux.Vars(r)
-	user := vars["id"]
-	dataStore := NewDataStore()
-	defer dataStore.Close()
-	col := dataStore.Collection("bookmarks")
-	bookmarkStore := store.BookmarkStore{C: col}
-	bookmarks := bookmarkStore.GetByUser(user)
-	j, err := json.Marshal(BookmarksResource{Data: bookmarks})
+	hits := make([]SearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, SearchHit{
+			Bookmark:   result.Bookmark,
+			Score:      result.Score,
+			Highlights: result.Highlights,
+		})
+	}
+	j, err := json.Marshal(SearchResource{Data: hits})
 	if err != nil {
 		utils.DisplayAppError(
 			w,
@@ -158,20 +171,24 @@ func GetBookmarksByUser(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(j)
 }
 
 // UpdateBookmark update an existing Bookmark document
 // Handler for HTTP Put - "/Bookmarks/{id}"
-func UpdateBookmark(w http.ResponseWriter, r *http.Request) {
+func (c BookmarkController) UpdateBookmark(w http.ResponseWriter, r *http.Request) {
 	// Get id from the incoming url
 	vars := mux.Vars(r)
-	id := bson.ObjectIdHex(vars["id"])
+	existing, err := c.authorize(r, vars["id"], true)
+	if err != nil {
+		displayStoreError(w, err)
+		return
+	}
 	var dataResource BookmarkResource
 	// Decode the incoming Bookmark json
-	err := json.NewDecoder(r.Body).Decode(&dataResource)
+	err = json.NewDecoder(r.Body).Decode(&dataResource)
 	if err != nil {
 		utils.DisplayAppError(
 			w,
@@ -182,19 +199,10 @@ func UpdateBookmark(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	bookmark := dataResource.Data
-	bookmark.ID = id
-	dataStore := NewDataStore()
-	defer dataStore.Close()
-	col := dataStore.Collection("bookmarks")
-	bookmarkStore := store.BookmarkStore{C: col}
+	bookmark.ID = existing.ID
 	// Update an existing Bookmark document
-	if err := bookmarkStore.Update(bookmark); err != nil {
-		utils.DisplayAppError(
-			w,
-			err,
-			"An unexpected error has occurred",
-			500,
-		)
+	if err := c.Store.Update(r.Context(), bookmark); err != nil {
+		displayStoreError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -203,15 +211,51 @@ func UpdateBookmark(w http.ResponseWriter, r *http.Request) {
 
 // DeleteBookmark deletes an existing Bookmark document
 // Handler for HTTP Delete - "/Bookmarks/{id}"
-func DeleteBookmark(w http.ResponseWriter, r *http.Request) {
+func (c BookmarkController) DeleteBookmark(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
-	dataStore := NewDataStore()
-	defer dataStore.Close()
-	col := dataStore.Collection("bookmarks")
-	bookmarkStore := store.BookmarkStore{C: col}
+	if _, err := c.authorize(r, id, true); err != nil {
+		displayStoreError(w, err)
+		return
+	}
 	// Delete an existing Bookmark document
-	err := bookmarkStore.Delete(id)
+	err := c.Store.Delete(r.Context(), id)
+	if err != nil {
+		displayStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareBookmark changes who can see a Bookmark; only its owner may
+// Handler for HTTP Put - "/Bookmarks/{id}/sharing"
+func (c BookmarkController) ShareBookmark(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	bookmark, err := c.authorize(r, id, true)
+	if err != nil {
+		displayStoreError(w, err)
+		return
+	}
+	var dataResource SharingResource
+	// Decode the incoming sharing json
+	if err := json.NewDecoder(r.Body).Decode(&dataResource); err != nil {
+		utils.DisplayAppError(w, err, "Invalid sharing data", http.StatusBadRequest)
+		return
+	}
+	sharing := dataResource.Data
+	if err := validateSharing(sharing.Visibility); err != nil || sharing.Visibility == "" {
+		utils.DisplayAppError(w, errors.New("visibility must be private, shared or public"),
+			"Invalid sharing data", http.StatusBadRequest)
+		return
+	}
+	bookmark.Visibility = sharing.Visibility
+	bookmark.SharedWith = sharedWith(sharing.Visibility, sharing.SharedWith, bookmark.CreatedBy)
+	if err := c.Store.SetSharing(r.Context(), id, bookmark.Visibility, bookmark.SharedWith); err != nil {
+		displayStoreError(w, err)
+		return
+	}
+	j, err := json.Marshal(BookmarkResource{Data: bookmark})
 	if err != nil {
 		utils.DisplayAppError(
 			w,
@@ -221,5 +265,124 @@ func DeleteBookmark(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// authorize loads the bookmark id for the current user. Bookmarks the
+// user may not see are reported as missing, so their existence isn't
+// revealed; bookmarks the user may see but not change give ErrForbidden.
+func (c BookmarkController) authorize(r *http.Request, id string, write bool) (model.Bookmark, error) {
+	bookmark, err := c.Store.GetByID(r.Context(), id)
+	if err != nil {
+		return bookmark, err
+	}
+	user := currentUser(r)
+	if !bookmark.CanRead(user) {
+		return model.Bookmark{}, model.ErrNotFound
+	}
+	if write && !bookmark.CanWrite(user) {
+		return model.Bookmark{}, model.ErrForbidden
+	}
+	return bookmark, nil
+}
+
+// listBookmarks sends the page of bookmarks selected by the query string,
+// further narrowed by scope
+func (c BookmarkController) listBookmarks(w http.ResponseWriter, r *http.Request, scope func(q *store.BookmarkQuery)) {
+	query, err := parseBookmarkQuery(r.URL.Query())
+	if err != nil {
+		utils.DisplayAppError(w, err, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	scope(&query)
+	query.VisibleTo = currentUser(r)
+	if query, err = query.Normalize(); err != nil {
+		utils.DisplayAppError(w, err, "Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	page, err := c.Store.List(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			utils.DisplayAppError(w, err, "Invalid next token", http.StatusBadRequest)
+			return
+		}
+		utils.DisplayAppError(
+			w,
+			err,
+			"An unexpected error has occurred",
+			500,
+		)
+		return
+	}
+	j, err := json.Marshal(BookmarksResource{
+		Data: page.Bookmarks,
+		Paging: &Paging{
+			Limit: query.Limit,
+			Count: len(page.Bookmarks),
+			Next:  page.Next,
+		},
+	})
+	if err != nil {
+		utils.DisplayAppError(
+			w,
+			err,
+			"An unexpected error has occurred",
+			500,
+		)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// displayStoreError sends 404 for missing documents, 403 for changes by
+// someone else than the owner and 500 otherwise
+func displayStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, model.ErrNotFound) {
+		utils.DisplayAppError(w, err, "Bookmark not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, model.ErrForbidden) {
+		utils.DisplayAppError(w, err, "Only the owner can change a Bookmark", http.StatusForbidden)
+		return
+	}
+	utils.DisplayAppError(
+		w,
+		err,
+		"An unexpected error has occurred",
+		500,
+	)
+}
+
+// currentUser returns the user name set on the context by apputil.AuthorizeRequest
+func currentUser(r *http.Request) string {
+	user, _ := r.Context().Value("user").(string)
+	return user
+}
+
+func validateSharing(visibility string) error {
+	if !model.ValidVisibility(visibility) {
+		return errors.New("visibility must be private, shared or public")
+	}
+	return nil
+}
+
+// sharedWith returns the distinct users to share with, without the owner;
+// only shared bookmarks keep a list
+func sharedWith(visibility string, users []string, owner string) []string {
+	if visibility != model.Shared {
+		return nil
+	}
+	seen := map[string]bool{owner: true}
+	result := []string{}
+	for _, u := range users {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			result = append(result, u)
+		}
+	}
+	return result
 }
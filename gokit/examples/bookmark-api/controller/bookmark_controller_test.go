@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/memstore"
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+)
+
+// setUpBookmarkRouter registers the bookmark handlers backed by an in-memory store
+func setUpBookmarkRouter(t *testing.T) (*mux.Router, *memstore.BookmarkStore) {
+	t.Helper()
+	store := memstore.NewBookmarkStore()
+	handler := BookmarkController{Store: store}
+	r := mux.NewRouter()
+	r.HandleFunc("/bookmarks", handler.CreateBookmark).Methods("POST")
+	r.HandleFunc("/bookmarks/{id}", handler.UpdateBookmark).Methods("PUT")
+	r.HandleFunc("/bookmarks/{id}/sharing", handler.ShareBookmark).Methods("PUT")
+	r.HandleFunc("/bookmarks", handler.GetBookmarks).Methods("GET")
+	r.HandleFunc("/bookmarks/search", handler.SearchBookmarks).Methods("GET")
+	r.HandleFunc("/bookmarks/broken", handler.BrokenBookmarks).Methods("GET")
+	r.HandleFunc("/bookmarks/export", handler.ExportBookmarks).Methods("GET")
+	r.HandleFunc("/bookmarks/import", handler.ImportBookmarks).Methods("POST")
+	r.HandleFunc("/bookmarks/{id}", handler.GetBookmarkByID).Methods("GET")
+	r.HandleFunc("/bookmarks/users/{id}", handler.GetBookmarksByUser).Methods("GET")
+	r.HandleFunc("/bookmarks/{id}", handler.DeleteBookmark).Methods("DELETE")
+	return r, store
+}
+
+// serve sends a request on behalf of user, as set by apputil.AuthorizeRequest
+func serve(r http.Handler, user, method, url, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), "user", user))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createBookmark(t *testing.T, r http.Handler, user, body string) model.Bookmark {
+	t.Helper()
+	w := serve(r, user, "POST", "/bookmarks", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusCreated, w.Code)
+	}
+	var resp BookmarkResource
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Data
+}
+
+func TestBookmarkController_CreateBookmark(t *testing.T) {
+	r, _ := setUpBookmarkRouter(t)
+	b := createBookmark(t, r, "gopher@example.com", `{"data": {"name": "Go", "location": "https://go.dev"}}`)
+	if b.ID.IsZero() || b.CreatedBy != "gopher@example.com" || b.CreatedOn.IsZero() {
+		t.Errorf("unexpected bookmark: %+v", b)
+	}
+}
+
+func TestBookmarkController_CreateBookmarkIgnoresCreatedOn(t *testing.T) {
+	r, _ := setUpBookmarkRouter(t)
+	b := createBookmark(t, r, "alice", `{"data": {"name": "Go", "createdon": "2009-11-10T23:00:00Z"}}`)
+	if b.CreatedOn.Year() == 2009 {
+		t.Errorf("expected the store to set the creation time, got: %v", b.CreatedOn)
+	}
+}
+
+func TestBookmarkController_GetBookmarks(t *testing.T) {
+	r, _ := setUpBookmarkRouter(t)
+	createBookmark(t, r, "alice", `{"data": {"name": "Low", "priority": 3}}`)
+	createBookmark(t, r, "bob", `{"data": {"name": "High", "priority": 1, "visibility": "public"}}`)
+	createBookmark(t, r, "bob", `{"data": {"name": "Private", "priority": 1}}`)
+
+	w := serve(r, "alice", "GET", "/bookmarks", "")
+	var resp BookmarksResource
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].Name != "High" {
+		t.Errorf("expected 2 bookmarks ordered by priority, got: %+v", resp.Data)
+	}
+
+	w = serve(r, "alice", "GET", "/bookmarks?limit=1&sort=-priority", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Name != "Low" || resp.Paging == nil || resp.Paging.Next == "" {
+		t.Fatalf("expected the first page with a next token, got: %+v %+v", resp.Data, resp.Paging)
+	}
+	w = serve(r, "alice", "GET", "/bookmarks?limit=1&sort=-priority&next="+resp.Paging.Next, "")
+	resp = BookmarksResource{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Name != "High" || resp.Paging.Next != "" {
+		t.Errorf("expected the last page, got: %+v %+v", resp.Data, resp.Paging)
+	}
+
+	w = serve(r, "alice", "GET", "/bookmarks/users/bob", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].CreatedBy != "bob" {
+		t.Errorf("expected the public bookmark of bob, got: %+v", resp.Data)
+	}
+}
+
+func TestBookmarkController_GetBookmarksInvalidQuery(t *testing.T) {
+	r, _ := setUpBookmarkRouter(t)
+	for _, query := range []string{
+		"limit=abc",
+		"limit=1000",
+		"sort=name",
+		"tagmatch=some",
+		"minpriority=4&maxpriority=2",
+		"from=yesterday",
+		"next=bogus",
+	} {
+		w := serve(r, "alice", "GET", "/bookmarks?"+query, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: HTTP Status expected: %d, got: %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestBookmarkController_UpdateAndDelete(t *testing.T) {
+	r, store := setUpBookmarkRouter(t)
+	b := createBookmark(t, r, "alice", `{"data": {"name": "Go"}}`)
+	url := "/bookmarks/" + b.ID.Hex()
+
+	if w := serve(r, "alice", "PUT", url, `{"data": {"name": "Golang", "tags": ["go"]}}`); w.Code != http.StatusNoContent {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusNoContent, w.Code)
+	}
+	updated, err := store.GetByID(context.Background(), b.ID.Hex())
+	if err != nil || updated.Name != "Golang" || len(updated.Tags) != 1 {
+		t.Errorf("unexpected bookmark after update: %+v, %v", updated, err)
+	}
+	if w := serve(r, "alice", "DELETE", url, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusNoContent, w.Code)
+	}
+
+	tests := []struct {
+		method, url string
+	}{
+		{"GET", url},
+		{"PUT", url},
+		{"DELETE", url},
+		{"GET", "/bookmarks/not-an-id"},
+	}
+	for _, tt := range tests {
+		w := serve(r, "alice", tt.method, tt.url, `{"data": {}}`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: HTTP Status expected: %d, got: %d", tt.method, tt.url, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestBookmarkController_Ownership(t *testing.T) {
+	r, _ := setUpBookmarkRouter(t)
+	b := createBookmark(t, r, "alice", `{"data": {"name": "Go"}}`)
+	url := "/bookmarks/" + b.ID.Hex()
+	if b.Visibility != model.Private {
+		t.Errorf("expected new bookmarks to be private, got: %q", b.Visibility)
+	}
+
+	// Private: hidden from bob
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		if w := serve(r, "bob", method, url, `{"data": {"name": "Mine"}}`); w.Code != http.StatusNotFound {
+			t.Errorf("%s private bookmark: HTTP Status expected: %d, got: %d", method, http.StatusNotFound, w.Code)
+		}
+	}
+	if w := serve(r, "bob", "PUT", url+"/sharing", `{"data": {"visibility": "public"}}`); w.Code != http.StatusNotFound {
+		t.Errorf("share private bookmark: HTTP Status expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+
+	// Shared with bob: readable, but only alice can change it
+	w := serve(r, "alice", "PUT", url+"/sharing", `{"data": {"visibility": "shared", "sharedwith": ["bob", "bob", "alice"]}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	var resp BookmarkResource
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.SharedWith) != 1 || resp.Data.SharedWith[0] != "bob" {
+		t.Errorf("expected to be shared with bob only, got: %v", resp.Data.SharedWith)
+	}
+	if w := serve(r, "bob", "GET", url, ""); w.Code != http.StatusOK {
+		t.Errorf("GET shared bookmark: HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	if w := serve(r, "carol", "GET", url, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET bookmark not shared with carol: HTTP Status expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+	for _, method := range []string{"PUT", "DELETE"} {
+		if w := serve(r, "bob", method, url, `{"data": {"name": "Mine"}}`); w.Code != http.StatusForbidden {
+			t.Errorf("%s shared bookmark: HTTP Status expected: %d, got: %d", method, http.StatusForbidden, w.Code)
+		}
+	}
+	var list BookmarksResource
+	w = serve(r, "bob", "GET", "/bookmarks/users/alice", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 {
+		t.Errorf("expected bob to see the shared bookmark of alice, got: %+v", list.Data)
+	}
+
+	if w := serve(r, "alice", "PUT", url+"/sharing", `{"data": {"visibility": "everyone"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid visibility: HTTP Status expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestBookmarkController_SearchBookmarks(t *testing.T) {
+	r, _ := setUpBookmarkRouter(t)
+	createBookmark(t, r, "alice", `{"data": {"name": "Go blog", "description": "Concurrency is not parallelism"}}`)
+	createBookmark(t, r, "alice", `{"data": {"name": "Concurrency in Go", "location": "https://example.com"}}`)
+	createBookmark(t, r, "bob", `{"data": {"name": "Concurrency", "description": "bob's bookmark"}}`)
+
+	w := serve(r, "alice", "GET", "/bookmarks/search?q=concurrency", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	var resp SearchResource
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("expected the 2 bookmarks of alice, got: %+v", resp.Data)
+	}
+	top := resp.Data[0]
+	if top.Bookmark.Name != "Concurrency in Go" || top.Score <= resp.Data[1].Score {
+		t.Errorf("expected the name match to rank first, got: %+v", resp.Data)
+	}
+	if top.Highlights["name"] != "<em>Concurrency</em> in Go" {
+		t.Errorf("unexpected highlights: %+v", top.Highlights)
+	}
+
+	if w := serve(r, "alice", "GET", "/bookmarks/search?q=", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestBookmarkController_BrokenBookmarks(t *testing.T) {
+	r, bookmarks := setUpBookmarkRouter(t)
+	ok := createBookmark(t, r, "alice", `{"data": {"name": "Up"}}`)
+	gone := createBookmark(t, r, "alice", `{"data": {"name": "Gone"}}`)
+	down := createBookmark(t, r, "alice", `{"data": {"name": "Down"}}`)
+	createBookmark(t, r, "alice", `{"data": {"name": "Unchecked"}}`)
+	other := createBookmark(t, r, "bob", `{"data": {"name": "Bob's", "visibility": "public"}}`)
+	ctx := context.Background()
+	now := time.Now()
+	bookmarks.SetHealth(ctx, ok.ID.Hex(), model.LinkHealth{Status: http.StatusOK, CheckedOn: now})
+	bookmarks.SetHealth(ctx, gone.ID.Hex(), model.LinkHealth{Status: http.StatusNotFound, CheckedOn: now})
+	bookmarks.SetHealth(ctx, down.ID.Hex(), model.LinkHealth{Error: "connection refused", CheckedOn: now})
+	bookmarks.SetHealth(ctx, other.ID.Hex(), model.LinkHealth{Status: http.StatusGone, CheckedOn: now})
+
+	w := serve(r, "alice", "GET", "/bookmarks/broken?sort=createdon", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	var resp BookmarksResource
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].Name != "Gone" || resp.Data[1].Name != "Down" {
+		t.Fatalf("expected the 2 broken bookmarks of alice, got: %+v", resp.Data)
+	}
+	if resp.Data[0].Health == nil || resp.Data[0].Health.Status != http.StatusNotFound {
+		t.Errorf("expected the health of the bookmark, got: %+v", resp.Data[0].Health)
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// parseBookmarkQuery reads the list parameters of the bookmark endpoints:
+//
+//	limit        page size, up to store.MaxLimit
+//	next         token from the paging.next of the previous page
+//	tags         comma separated tags
+//	tagmatch     "any" (default) or "all" of the tags
+//	minpriority  lowest priority, inclusive
+//	maxpriority  highest priority, inclusive
+//	from, to     created date range as RFC 3339 or YYYY-MM-DD; a date-only
+//	             "to" includes the whole day
+//	sort         priority (default), -priority, createdon or -createdon
+func parseBookmarkQuery(v url.Values) (store.BookmarkQuery, error) {
+	var q store.BookmarkQuery
+	var err error
+	if q.Limit, err = intParam(v, "limit"); err != nil {
+		return q, err
+	}
+	if q.MinPriority, err = intParam(v, "minpriority"); err != nil {
+		return q, err
+	}
+	if q.MaxPriority, err = intParam(v, "maxpriority"); err != nil {
+		return q, err
+	}
+	if q.CreatedFrom, err = timeParam(v, "from", false); err != nil {
+		return q, err
+	}
+	if q.CreatedTo, err = timeParam(v, "to", true); err != nil {
+		return q, err
+	}
+	if tags := v.Get("tags"); tags != "" {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				q.Tags = append(q.Tags, tag)
+			}
+		}
+	}
+	q.TagMatch = store.TagMatch(v.Get("tagmatch"))
+	q.Sort = v.Get("sort")
+	q.Cursor = v.Get("next")
+	return q, nil
+}
+
+func intParam(v url.Values, name string) (int, error) {
+	s := v.Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return n, nil
+}
+
+// timeParam parses an RFC 3339 time or a date. With endOfDay a date
+// means the start of the next day, for exclusive upper bounds.
+func timeParam(v url.Values, name string, endOfDay bool) (time.Time, error) {
+	s := v.Get(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return t, fmt.Errorf("%s must be an RFC 3339 time or a YYYY-MM-DD date", name)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
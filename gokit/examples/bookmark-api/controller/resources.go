@@ -21,7 +21,56 @@ type (
 	}
 	// BookmarksResource for Get - /bookmarks
 	BookmarksResource struct {
-		Data []model.Bookmark `json:"data"`
+		Data   []model.Bookmark `json:"data"`
+		Paging *Paging          `json:"paging,omitempty"`
+	}
+	// SharingResource for Put - /bookmarks/{id}/sharing
+	SharingResource struct {
+		Data SharingModel `json:"data"`
+	}
+	// SharingModel sets who can see a bookmark
+	SharingModel struct {
+		Visibility string   `json:"visibility"`
+		SharedWith []string `json:"sharedwith"`
+	}
+	// SearchResource for Get - /bookmarks/search
+	SearchResource struct {
+		Data []SearchHit `json:"data"`
+	}
+	// SearchHit is a bookmark matching a search with its relevance score
+	// and highlighted snippets of the matching fields
+	SearchHit struct {
+		Bookmark   model.Bookmark    `json:"bookmark"`
+		Score      float64           `json:"score"`
+		Highlights map[string]string `json:"highlights,omitempty"`
+	}
+	// ImportResource Response for Post - /bookmarks/import
+	ImportResource struct {
+		Data ImportReport `json:"data"`
+	}
+	// ImportReport counts the outcomes of an import and lists the result
+	// of every item in the order of the imported file
+	ImportReport struct {
+		Imported   int          `json:"imported"`
+		Duplicates int          `json:"duplicates"`
+		Failed     int          `json:"failed"`
+		Items      []ImportItem `json:"items"`
+	}
+	// ImportItem is the result of importing a single bookmark
+	ImportItem struct {
+		Index    int    `json:"index"`
+		Name     string `json:"name"`
+		Location string `json:"location"`
+		Status   string `json:"status"` // imported, duplicate, invalid or failed
+		ID       string `json:"id,omitempty"`
+		Error    string `json:"error,omitempty"`
+	}
+	// Paging describes a page of a list; pass Next as the "next" query
+	// parameter to get the following page
+	Paging struct {
+		Limit int    `json:"limit"`
+		Count int    `json:"count"`
+		Next  string `json:"next,omitempty"`
 	}
 
 	// UserModel reperesents a user
@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	utils "github.com/shijuvar/gokit/examples/bookmark-api/apputil"
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/netscape"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// maxImportSize limits the size of an imported bookmark file
+const maxImportSize = 10 << 20
+
+// Import item statuses
+const (
+	importImported  = "imported"
+	importDuplicate = "duplicate"
+	importInvalid   = "invalid"
+	importFailed    = "failed"
+)
+
+// ImportBookmarks imports a Netscape bookmark file, as exported by
+// browsers, or a JSON export. Folders become tags. Bookmarks whose
+// location the user already has, or which appear earlier in the same
+// file, are reported as duplicates and skipped.
+// Handler for HTTP Post - "/bookmarks/import"
+func (c BookmarkController) ImportBookmarks(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	var bookmarks []model.Bookmark
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var dataResource BookmarksResource
+		err = json.NewDecoder(body).Decode(&dataResource)
+		bookmarks = dataResource.Data
+	} else {
+		bookmarks, err = netscape.Parse(body)
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.DisplayAppError(w, err, "Bookmark file is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		utils.DisplayAppError(w, err, "Invalid bookmark file", http.StatusBadRequest)
+		return
+	}
+
+	locations := make([]string, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		locations = append(locations, strings.TrimSpace(b.Location))
+	}
+	seen, err := c.Store.ExistingLocations(r.Context(), user, locations)
+	if err != nil {
+		utils.DisplayAppError(
+			w,
+			err,
+			"An unexpected error has occurred",
+			500,
+		)
+		return
+	}
+
+	report := ImportReport{Items: make([]ImportItem, 0, len(bookmarks))}
+	for i, b := range bookmarks {
+		b.Location = strings.TrimSpace(b.Location)
+		if b.Name = strings.TrimSpace(b.Name); b.Name == "" {
+			b.Name = b.Location
+		}
+		item := ImportItem{Index: i, Name: b.Name, Location: b.Location}
+		switch {
+		case b.Location == "":
+			item.Status = importInvalid
+			item.Error = "location is required"
+			report.Failed++
+		case seen[b.Location]:
+			item.Status = importDuplicate
+			report.Duplicates++
+		default:
+			bookmark := model.Bookmark{
+				Name:        b.Name,
+				Description: b.Description,
+				Location:    b.Location,
+				Priority:    b.Priority,
+				Tags:        b.Tags,
+				CreatedOn:   b.CreatedOn, // ADD_DATE, if any
+				CreatedBy:   user,
+				Visibility:  model.Private,
+			}
+			if err := c.Store.Create(r.Context(), &bookmark); err != nil {
+				utils.Logger.Error("Import of bookmark failed", "location", b.Location, "error", err)
+				item.Status = importFailed
+				item.Error = "could not be saved"
+				report.Failed++
+				break
+			}
+			seen[b.Location] = true
+			item.Status = importImported
+			item.ID = bookmark.ID.Hex()
+			report.Imported++
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	j, err := json.Marshal(ImportResource{Data: report})
+	if err != nil {
+		utils.DisplayAppError(
+			w,
+			err,
+			"An unexpected error has occurred",
+			500,
+		)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(j)
+}
+
+// ExportBookmarks streams the bookmarks of the user, oldest first, as a
+// Netscape bookmark file (format=html) or as JSON (format=json, the default)
+// Handler for HTTP Get - "/bookmarks/export"
+func (c BookmarkController) ExportBookmarks(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "html" {
+		utils.DisplayAppError(w, errors.New("format must be html or json"),
+			"Invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	query := store.BookmarkQuery{
+		CreatedBy: currentUser(r),
+		Sort:      store.SortCreatedOn,
+		Limit:     store.MaxLimit,
+	}
+	// Fetch the first page before writing, so errors still get a status
+	page, err := c.Store.List(r.Context(), query)
+	if err != nil {
+		utils.DisplayAppError(
+			w,
+			err,
+			"An unexpected error has occurred",
+			500,
+		)
+		return
+	}
+
+	var enc exportEncoder
+	if format == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="bookmarks.html"`)
+		enc = htmlExport{netscape.NewWriter(w)}
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="bookmarks.json"`)
+		enc = &jsonExport{w: w}
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	for {
+		for _, b := range page.Bookmarks {
+			if err := enc.Write(b); err != nil {
+				utils.Logger.Error("Export of bookmarks failed", "error", err)
+				return
+			}
+		}
+		if page.Next == "" {
+			break
+		}
+		if err := enc.Flush(); err != nil {
+			utils.Logger.Error("Export of bookmarks failed", "error", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		query.Cursor = page.Next
+		if page, err = c.Store.List(r.Context(), query); err != nil {
+			// The status is sent already; the client gets a truncated file
+			utils.Logger.Error("Export of bookmarks failed", "error", err)
+			return
+		}
+	}
+	if err := enc.Close(); err != nil {
+		utils.Logger.Error("Export of bookmarks failed", "error", err)
+	}
+}
+
+// exportEncoder writes the bookmarks of an export one at a time
+type exportEncoder interface {
+	Write(model.Bookmark) error
+	Flush() error
+	Close() error
+}
+
+type htmlExport struct {
+	*netscape.Writer
+}
+
+// jsonExport writes a BookmarksResource incrementally
+type jsonExport struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (e *jsonExport) Write(b model.Bookmark) error {
+	prefix := ","
+	if !e.started {
+		prefix = `{"data":[`
+		e.started = true
+	}
+	j, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write([]byte(prefix)); err != nil {
+		return err
+	}
+	_, err = e.w.Write(j)
+	return err
+}
+
+func (e *jsonExport) Flush() error {
+	return nil
+}
+
+func (e *jsonExport) Close() error {
+	if !e.started {
+		_, err := e.w.Write([]byte(`{"data":[]}`))
+		return err
+	}
+	_, err := e.w.Write([]byte("]}"))
+	return err
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/netscape"
+)
+
+const importFile = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><H3>Go</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev" ADD_DATE="1257894000">Go</A>
+        <DT><A HREF="https://pkg.go.dev">Packages</A>
+        <DT><A HREF="https://go.dev">Go again</A>
+        <DT><A HREF="">Broken</A>
+    </DL><p>
+</DL><p>
+`
+
+func TestBookmarkController_ImportBookmarks(t *testing.T) {
+	r, store := setUpBookmarkRouter(t)
+	createBookmark(t, r, "alice", `{"data": {"name": "Packages", "location": "https://pkg.go.dev"}}`)
+
+	w := serve(r, "alice", "POST", "/bookmarks/import", importFile)
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	var resp ImportResource
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	report := resp.Data
+	if report.Imported != 1 || report.Duplicates != 2 || report.Failed != 1 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+	statuses := []string{}
+	for _, item := range report.Items {
+		statuses = append(statuses, item.Status)
+	}
+	if got := strings.Join(statuses, ","); got != "imported,duplicate,duplicate,invalid" {
+		t.Errorf("unexpected item statuses: %s", got)
+	}
+	b, err := store.GetByID(context.Background(), report.Items[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.CreatedBy != "alice" || len(b.Tags) != 1 || b.Tags[0] != "Go" {
+		t.Errorf("expected the folder to become a tag, got: %+v", b)
+	}
+	if want := time.Unix(1257894000, 0); !b.CreatedOn.Equal(want) {
+		t.Errorf("expected ADD_DATE %v as creation time, got: %v", want, b.CreatedOn)
+	}
+
+	// Duplicates are per user
+	w = serve(r, "bob", "POST", "/bookmarks/import", importFile)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Imported != 2 {
+		t.Errorf("expected bob to import 2 bookmarks, got: %+v", resp.Data)
+	}
+}
+
+func TestBookmarkController_ExportBookmarks(t *testing.T) {
+	r, store := setUpBookmarkRouter(t)
+	// More than one page of the store
+	for i := 0; i < 105; i++ {
+		b := model.Bookmark{Name: fmt.Sprint("Bookmark ", i), Location: fmt.Sprint("https://example.com/", i),
+			CreatedBy: "alice", Tags: []string{"t"}}
+		if err := store.Create(context.Background(), &b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createBookmark(t, r, "bob", `{"data": {"name": "Bob", "location": "https://bob.example.com"}}`)
+
+	w := serve(r, "alice", "GET", "/bookmarks/export?format=json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	var resp BookmarksResource
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON export: %v", err)
+	}
+	if len(resp.Data) != 105 {
+		t.Errorf("expected the 105 bookmarks of alice, got: %d", len(resp.Data))
+	}
+
+	w = serve(r, "alice", "GET", "/bookmarks/export?format=html", "")
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("unexpected Content-Type: %q", ct)
+	}
+	exported, err := netscape.Parse(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 105 || exported[0].Location != "https://example.com/0" {
+		t.Errorf("expected the 105 bookmarks of alice oldest first, got: %d", len(exported))
+	}
+
+	if w := serve(r, "alice", "GET", "/bookmarks/export?format=csv", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+}
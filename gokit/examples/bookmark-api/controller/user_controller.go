@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	utils "github.com/shijuvar/gokit/examples/bookmark-api/apputil"
@@ -9,9 +10,14 @@ import (
 	"github.com/shijuvar/gokit/examples/bookmark-api/store"
 )
 
+// UserController handles user registration and login
+type UserController struct {
+	Store store.UserStore // injected, so it can be replaced in tests
+}
+
 // Register add a new User document
 // Handler for HTTP Post - "/users/register"
-func Register(w http.ResponseWriter, r *http.Request) {
+func (c UserController) Register(w http.ResponseWriter, r *http.Request) {
 	var dataResource UserResource
 	// Decode the incoming User json
 	err := json.NewDecoder(r.Body).Decode(&dataResource)
@@ -25,24 +31,32 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	userModel := dataResource.Data
-	dataStore := NewDataStore()
-	defer dataStore.Close()
-	col := dataStore.Collection("users")
-	userStore := store.UserStore{C: col}
 	user := model.User{
 		FirstName: userModel.FirstName,
 		LastName:  userModel.LastName,
 		Email:     userModel.Email,
 	}
 	// Insert User document
-	userStore.Create(user, userModel.Password)
+	if err := c.Store.Create(r.Context(), user, userModel.Password); err != nil {
+		if errors.Is(err, model.ErrEmailExists) {
+			utils.DisplayAppError(w, err, "Email is already registered", http.StatusConflict)
+			return
+		}
+		utils.DisplayAppError(
+			w,
+			err,
+			"An unexpected error has occurred",
+			500,
+		)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 }
 
 // Login authenticates the HTTP request with username and apssword
 // Handler for HTTP Post - "/users/login"
-func Login(w http.ResponseWriter, r *http.Request) {
+func (c UserController) Login(w http.ResponseWriter, r *http.Request) {
 	var dataResource UserResource
 	var token string
 	// Decode the incoming Login json
@@ -57,18 +71,18 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	loginUser := dataResource.Data
-	dataStore := NewDataStore()
-	defer dataStore.Close()
-	col := dataStore.Collection("users")
-	userStore := store.UserStore{C: col}
 	// Authenticate the login user
-	user, err := userStore.Login(loginUser.Email, loginUser.Password)
+	user, err := c.Store.Login(r.Context(), loginUser.Email, loginUser.Password)
 	if err != nil {
+		status := 500
+		if errors.Is(err, model.ErrInvalidCredentials) {
+			status = 401
+		}
 		utils.DisplayAppError(
 			w,
 			err,
 			"Invalid login credentials",
-			401,
+			status,
 		)
 		return
 	}
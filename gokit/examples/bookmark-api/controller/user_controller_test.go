@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/memstore"
+)
+
+func TestUserController_RegisterAndLogin(t *testing.T) {
+	handler := UserController{Store: memstore.NewUserStore()}
+	register := func(body string) int {
+		w := httptest.NewRecorder()
+		handler.Register(w, httptest.NewRequest("POST", "/users", strings.NewReader(body)))
+		return w.Code
+	}
+	body := `{"data": {"email": "gopher@example.com", "password": "secret"}}`
+	if code := register(body); code != http.StatusCreated {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusCreated, code)
+	}
+	if code := register(body); code != http.StatusConflict {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusConflict, code)
+	}
+
+	w := httptest.NewRecorder()
+	handler.Login(w, httptest.NewRequest("POST", "/users/login",
+		strings.NewReader(`{"data": {"email": "gopher@example.com", "password": "wrong"}}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusUnauthorized, w.Code)
+	}
+}
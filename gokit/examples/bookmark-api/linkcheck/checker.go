@@ -0,0 +1,254 @@
+// Package linkcheck periodically fetches the Location of every bookmark
+// and records whether the link still works, where it redirects to and
+// the title of the page
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	util "github.com/shijuvar/gokit/examples/bookmark-api/apputil"
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// ErrPrivateAddress is returned when a link resolves to a loopback,
+// private or link-local address and Config.AllowPrivateNetworks is off
+var ErrPrivateAddress = errors.New("private network address")
+
+// Config tunes a Checker. Zero values are replaced by defaults.
+type Config struct {
+	// Interval is how often a link is checked again (default 24h)
+	Interval time.Duration
+	// Poll is how often the store is asked for links due (default 1m)
+	Poll time.Duration
+	// Concurrency is the number of links fetched at once (default 8)
+	Concurrency int
+	// HostDelay is the minimum time between two requests to the same
+	// host (default 1s)
+	HostDelay time.Duration
+	// Timeout bounds each check, redirects included (default 15s)
+	Timeout time.Duration
+	// BatchSize is the number of links taken per poll (default 100)
+	BatchSize int
+	// UserAgent is sent with every request
+	UserAgent string
+	// MaxBody is how much of an HTML page is read to find its title
+	// (default 1MB)
+	MaxBody int64
+	// AllowPrivateNetworks permits links to loopback and private
+	// addresses; only meant for tests and trusted deployments
+	AllowPrivateNetworks bool
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	if cfg.Poll <= 0 {
+		cfg.Poll = time.Minute
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 8
+	}
+	if cfg.HostDelay < 0 {
+		cfg.HostDelay = 0
+	} else if cfg.HostDelay == 0 {
+		cfg.HostDelay = time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "bookmark-api-linkcheck/1.0"
+	}
+	if cfg.MaxBody <= 0 {
+		cfg.MaxBody = 1 << 20
+	}
+	return cfg
+}
+
+// Checker checks the links of the bookmarks in a store
+type Checker struct {
+	Store  store.BookmarkStore
+	config Config
+	client *http.Client
+	hosts  *hostLimiter
+}
+
+// NewChecker returns a Checker for the bookmarks of s. A negative
+// cfg.HostDelay disables the per host delay.
+func NewChecker(s store.BookmarkStore, cfg Config) *Checker {
+	cfg = cfg.withDefaults()
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		// Checked on the resolved address, so DNS names pointing at
+		// internal hosts are refused too
+		dialer.Control = denyPrivate
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: cfg.Timeout,
+	}
+	return &Checker{
+		Store:  s,
+		config: cfg,
+		client: &http.Client{Transport: transport},
+		hosts:  newHostLimiter(cfg.HostDelay),
+	}
+}
+
+// Run checks the links due every Config.Poll until ctx is done
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Poll)
+	defer ticker.Stop()
+	for {
+		n, err := c.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			util.Logger.Error("link check failed", "error", err)
+		} else if n > 0 {
+			util.Logger.Info("links checked", "count", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks one batch of links which were never checked or not
+// within Config.Interval, and returns the number of links checked
+func (c *Checker) RunOnce(ctx context.Context) (int, error) {
+	due, err := c.Store.DueForCheck(ctx, time.Now().Add(-c.config.Interval), c.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	jobs := make(chan model.Bookmark)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+		errs    []error
+	)
+	for i := 0; i < c.config.Concurrency && i < len(due); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				health := c.Check(ctx, b.Location)
+				if ctx.Err() != nil {
+					// Cancelled, not broken; check it again next time
+					continue
+				}
+				err := c.Store.SetHealth(ctx, b.ID.Hex(), health)
+				mu.Lock()
+				if err != nil && !errors.Is(err, model.ErrNotFound) {
+					errs = append(errs, err)
+				} else {
+					checked++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, b := range due {
+		select {
+		case jobs <- b:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return checked, errors.Join(errs...)
+}
+
+// Check fetches location, following redirects, and reports the result.
+// It waits for its turn if the host was requested within HostDelay.
+func (c *Checker) Check(ctx context.Context, location string) model.LinkHealth {
+	health := model.LinkHealth{CheckedOn: time.Now().UTC().Truncate(time.Millisecond)}
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		health.Error = "unsupported URL"
+		return health
+	}
+	if err := c.hosts.wait(ctx, u.Host); err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	req.Header.Set("User-Agent", c.config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		health.Error = errorText(err)
+		return health
+	}
+	defer resp.Body.Close()
+	health.Status = resp.StatusCode
+	health.FinalURL = resp.Request.URL.String()
+	if isHTML(resp.Header.Get("Content-Type")) {
+		health.Title = Title(io.LimitReader(resp.Body, c.config.MaxBody))
+	}
+	return health
+}
+
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// errorText drops the method and URL which *url.Error adds, as the
+// URL is already the Location of the bookmark
+func errorText(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return err.Error()
+}
+
+// denyPrivate is a net.Dialer Control function refusing connections to
+// addresses that are not reachable from the internet
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/memstore"
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// newWeb starts the stand-in web used by the tests
+func newWeb(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><title>\n  The Go\n Blog </title></head><body>Hi</body></html>"))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testConfig() Config {
+	return Config{AllowPrivateNetworks: true, HostDelay: -1, Timeout: 5 * time.Second}
+}
+
+func TestCheck(t *testing.T) {
+	web := newWeb(t)
+	c := NewChecker(memstore.NewBookmarkStore(), testConfig())
+	ctx := context.Background()
+
+	h := c.Check(ctx, web.URL+"/moved")
+	if h.Status != http.StatusOK || h.FinalURL != web.URL+"/page" || h.Title != "The Go Blog" {
+		t.Errorf("unexpected health of a redirect: %+v", h)
+	}
+	if h.Broken() || h.CheckedOn.IsZero() {
+		t.Errorf("expected a working link checked now, got: %+v", h)
+	}
+
+	h = c.Check(ctx, web.URL+"/gone")
+	if h.Status != http.StatusGone || h.Title != "" || !h.Broken() {
+		t.Errorf("unexpected health of a missing page: %+v", h)
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	h = c.Check(ctx, closed.URL)
+	if h.Status != 0 || h.Error == "" || !h.Broken() {
+		t.Errorf("unexpected health of an unreachable host: %+v", h)
+	}
+
+	h = c.Check(ctx, "mailto:gopher@example.com")
+	if h.Error != "unsupported URL" {
+		t.Errorf("unexpected health of a mailto link: %+v", h)
+	}
+}
+
+func TestCheckDeniesPrivateNetworks(t *testing.T) {
+	web := newWeb(t)
+	c := NewChecker(memstore.NewBookmarkStore(), Config{HostDelay: -1})
+	h := c.Check(context.Background(), web.URL+"/page")
+	if h.Status != 0 || !strings.Contains(h.Error, ErrPrivateAddress.Error()) {
+		t.Errorf("expected the loopback address to be refused, got: %+v", h)
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	web := newWeb(t)
+	bookmarks := memstore.NewBookmarkStore()
+	ctx := context.Background()
+	for _, path := range []string{"/page", "/moved", "/gone"} {
+		if err := bookmarks.Create(ctx, &model.Bookmark{Name: path, Location: web.URL + path, CreatedBy: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewChecker(bookmarks, testConfig())
+
+	n, err := c.RunOnce(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 links checked, got: %d, %v", n, err)
+	}
+	q, err := store.BookmarkQuery{Broken: true}.Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := bookmarks.List(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Bookmarks) != 1 || page.Bookmarks[0].Name != "/gone" {
+		t.Errorf("expected /gone to be broken, got: %+v", page.Bookmarks)
+	}
+	// Nothing is due again before Interval has passed
+	if n, err := c.RunOnce(ctx); err != nil || n != 0 {
+		t.Errorf("expected no links due, got: %d, %v", n, err)
+	}
+}
+
+func TestRunOnceLimits(t *testing.T) {
+	const delay = 40 * time.Millisecond
+	var (
+		inFlight, peak atomic.Int32
+		mu             sync.Mutex
+		hits           = map[string][]time.Time{}
+	)
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		mu.Lock()
+		hits[r.Host] = append(hits[r.Host], time.Now())
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer web.Close()
+
+	bookmarks := memstore.NewBookmarkStore()
+	ctx := context.Background()
+	// "localhost" and "127.0.0.1" are two hosts for politeness
+	port := web.URL[strings.LastIndex(web.URL, ":"):]
+	for i := 0; i < 3; i++ {
+		for _, host := range []string{"http://127.0.0.1", "http://localhost"} {
+			b := &model.Bookmark{Location: host + port + "/", CreatedBy: "alice"}
+			if err := bookmarks.Create(ctx, b); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	cfg := testConfig()
+	cfg.Concurrency = 2
+	cfg.HostDelay = delay
+	n, err := NewChecker(bookmarks, cfg).RunOnce(ctx)
+	if err != nil || n != 6 {
+		t.Fatalf("expected 6 links checked, got: %d, %v", n, err)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got: %d", p)
+	}
+	for host, times := range hits {
+		for i := 1; i < len(times); i++ {
+			// Allow for the time between reserving a slot and the request
+			if gap := times[i].Sub(times[i-1]); gap < delay-5*time.Millisecond {
+				t.Errorf("requests to %s only %v apart", host, gap)
+			}
+		}
+	}
+}
+
+func TestRunOnceCancelled(t *testing.T) {
+	web := newWeb(t)
+	bookmarks := memstore.NewBookmarkStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &model.Bookmark{Location: web.URL + "/page", CreatedBy: "alice"}
+	if err := bookmarks.Create(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if n, err := NewChecker(bookmarks, testConfig()).RunOnce(ctx); n != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected nothing checked after cancel, got: %d, %v", n, err)
+	}
+	if got, _ := bookmarks.GetByID(context.Background(), b.ID.Hex()); got.Health != nil {
+		t.Errorf("a cancelled check must not be recorded, got: %+v", got.Health)
+	}
+}
@@ -0,0 +1,60 @@
+package linkcheck
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter spaces out requests to the same host. Each caller reserves
+// the next free slot of its host, so concurrent workers queue up rather
+// than hitting the host together.
+type hostLimiter struct {
+	delay time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time // earliest time of the next request per host
+}
+
+func newHostLimiter(delay time.Duration) *hostLimiter {
+	return &hostLimiter{delay: delay, next: make(map[string]time.Time)}
+}
+
+// wait blocks until a request to host is allowed or ctx is done
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	if l.delay <= 0 {
+		return nil
+	}
+	host = strings.ToLower(host)
+	now := time.Now()
+
+	l.mu.Lock()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(l.delay)
+	// Forget hosts whose slot has passed, so the map stays small
+	if len(l.next) > 1024 {
+		for h, t := range l.next {
+			if t.Before(now) {
+				delete(l.next, h)
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
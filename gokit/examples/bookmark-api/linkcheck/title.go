@@ -0,0 +1,60 @@
+package linkcheck
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxTitle is the longest title kept, in bytes
+const maxTitle = 300
+
+// Title returns the text of the first <title> element of an HTML
+// document, with white space collapsed, or "" if there is none
+func Title(r io.Reader) string {
+	z := html.NewTokenizer(r)
+	depth := 0 // of <svg> elements, which may have their own <title>
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Svg:
+				depth++
+			case atom.Title:
+				if depth == 0 {
+					return readTitle(z)
+				}
+			case atom.Body:
+				if depth == 0 {
+					return ""
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if atom.Lookup(name) == atom.Svg && depth > 0 {
+				depth--
+			}
+		}
+	}
+}
+
+func readTitle(z *html.Tokenizer) string {
+	var b strings.Builder
+	for {
+		tt := z.Next()
+		if tt != html.TextToken {
+			break
+		}
+		b.Write(z.Text())
+	}
+	title := strings.Join(strings.Fields(b.String()), " ")
+	if len(title) > maxTitle {
+		title = strings.ToValidUTF8(title[:maxTitle], "")
+	}
+	return title
+}
@@ -0,0 +1,307 @@
+// Package memstore implements the bookmark-api stores in memory, for tests
+// and local development
+package memstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/search"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+var _ store.BookmarkStore = (*BookmarkStore)(nil)
+
+// BookmarkStore is an in-memory store.BookmarkStore. Search uses an
+// inverted index kept up to date on every write.
+type BookmarkStore struct {
+	mu        sync.RWMutex
+	bookmarks map[primitive.ObjectID]model.Bookmark
+	index     *search.Index
+}
+
+// NewBookmarkStore returns an empty BookmarkStore
+func NewBookmarkStore() *BookmarkStore {
+	return &BookmarkStore{
+		bookmarks: make(map[primitive.ObjectID]model.Bookmark),
+		index:     search.NewIndex(),
+	}
+}
+
+// Create assigns a new ID and, unless set, the creation time and stores b
+func (s *BookmarkStore) Create(ctx context.Context, b *model.Bookmark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b.ID = primitive.NewObjectID()
+	if b.CreatedOn.IsZero() {
+		b.CreatedOn = time.Now()
+	}
+	s.bookmarks[b.ID] = clone(*b)
+	s.index.Add(b.ID.Hex(), searchFields(*b)...)
+	return nil
+}
+
+// Update modifies the editable fields of an existing bookmark
+func (s *BookmarkStore) Update(ctx context.Context, b model.Bookmark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.bookmarks[b.ID]
+	if !ok {
+		return model.ErrNotFound
+	}
+	existing.Name = b.Name
+	existing.Description = b.Description
+	if existing.Location != b.Location {
+		// The last check was of the old link
+		existing.Health = nil
+	}
+	existing.Location = b.Location
+	existing.Priority = b.Priority
+	existing.Tags = append([]string(nil), b.Tags...)
+	s.bookmarks[b.ID] = existing
+	s.index.Add(b.ID.Hex(), searchFields(existing)...)
+	return nil
+}
+
+// SetSharing changes the visibility of an existing bookmark
+func (s *BookmarkStore) SetSharing(ctx context.Context, id, visibility string, sharedWith []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.ErrNotFound
+	}
+	existing, ok := s.bookmarks[oid]
+	if !ok {
+		return model.ErrNotFound
+	}
+	existing.Visibility = visibility
+	existing.SharedWith = append([]string(nil), sharedWith...)
+	s.bookmarks[oid] = existing
+	return nil
+}
+
+// Delete removes the bookmark with the given id
+func (s *BookmarkStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.ErrNotFound
+	}
+	if _, ok := s.bookmarks[oid]; !ok {
+		return model.ErrNotFound
+	}
+	delete(s.bookmarks, oid)
+	s.index.Remove(id)
+	return nil
+}
+
+// GetByID returns the bookmark with the given id
+func (s *BookmarkStore) GetByID(ctx context.Context, id string) (model.Bookmark, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.Bookmark{}, model.ErrNotFound
+	}
+	b, ok := s.bookmarks[oid]
+	if !ok {
+		return model.Bookmark{}, model.ErrNotFound
+	}
+	return clone(b), nil
+}
+
+// List returns a page of the bookmarks matching q
+func (s *BookmarkStore) List(ctx context.Context, q store.BookmarkQuery) (store.BookmarkPage, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return store.BookmarkPage{}, err
+	}
+	keys := q.SortKeys()
+	var after *model.Bookmark
+	if q.Cursor != "" {
+		pos, err := store.DecodeCursor(q)
+		if err != nil {
+			return store.BookmarkPage{}, err
+		}
+		after = &pos
+	}
+
+	s.mu.RLock()
+	matches := []model.Bookmark{}
+	for _, b := range s.bookmarks {
+		if matchQuery(q, b) && (after == nil || store.Less(keys, *after, b)) {
+			matches = append(matches, clone(b))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool { return store.Less(keys, matches[i], matches[j]) })
+	page := store.BookmarkPage{Bookmarks: matches}
+	if len(matches) > q.Limit {
+		page.Bookmarks = matches[:q.Limit]
+		page.Next = store.EncodeCursor(q.Sort, page.Bookmarks[q.Limit-1])
+	}
+	return page, nil
+}
+
+// ExistingLocations returns which of locations are used by bookmarks of user
+func (s *BookmarkStore) ExistingLocations(ctx context.Context, user string, locations []string) (map[string]bool, error) {
+	wanted := make(map[string]bool, len(locations))
+	for _, l := range locations {
+		wanted[l] = true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	existing := make(map[string]bool)
+	for _, b := range s.bookmarks {
+		if b.CreatedBy == user && wanted[b.Location] {
+			existing[b.Location] = true
+		}
+	}
+	return existing, nil
+}
+
+// DueForCheck returns bookmarks never checked or checked before the given time
+func (s *BookmarkStore) DueForCheck(ctx context.Context, before time.Time, limit int) ([]model.Bookmark, error) {
+	s.mu.RLock()
+	due := []model.Bookmark{}
+	for _, b := range s.bookmarks {
+		if b.Health == nil || b.Health.CheckedOn.Before(before) {
+			due = append(due, clone(b))
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(due, func(i, j int) bool {
+		return checkedOn(due[i]).Before(checkedOn(due[j]))
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func checkedOn(b model.Bookmark) time.Time {
+	if b.Health == nil {
+		return time.Time{}
+	}
+	return b.Health.CheckedOn
+}
+
+// SetHealth records the result of a link check
+func (s *BookmarkStore) SetHealth(ctx context.Context, id string, health model.LinkHealth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return model.ErrNotFound
+	}
+	existing, ok := s.bookmarks[oid]
+	if !ok {
+		return model.ErrNotFound
+	}
+	existing.Health = &health
+	s.bookmarks[oid] = existing
+	return nil
+}
+
+// Search returns the bookmarks matching q, ranked by TF-IDF
+func (s *BookmarkStore) Search(ctx context.Context, q store.SearchQuery) ([]store.SearchResult, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hits := s.index.Search(q.Text, func(id string) bool {
+		oid, _ := primitive.ObjectIDFromHex(id)
+		return q.CreatedBy == "" || s.bookmarks[oid].CreatedBy == q.CreatedBy
+	})
+	if len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	results := make([]store.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		oid, _ := primitive.ObjectIDFromHex(hit.ID)
+		b := clone(s.bookmarks[oid])
+		results = append(results, store.SearchResult{
+			Bookmark:   b,
+			Score:      hit.Score,
+			Highlights: store.Highlights(b, q.Text),
+		})
+	}
+	return results, nil
+}
+
+// searchFields returns the weighted searchable fields of b
+func searchFields(b model.Bookmark) []search.Field {
+	return []search.Field{
+		{Text: b.Name, Weight: float64(store.SearchWeights["name"])},
+		{Text: b.Description, Weight: float64(store.SearchWeights["description"])},
+		{Text: b.Location, Weight: float64(store.SearchWeights["location"])},
+	}
+}
+
+// matchQuery reports whether b passes the filters of q
+func matchQuery(q store.BookmarkQuery, b model.Bookmark) bool {
+	if q.CreatedBy != "" && b.CreatedBy != q.CreatedBy {
+		return false
+	}
+	if q.VisibleTo != "" && !b.CanRead(q.VisibleTo) {
+		return false
+	}
+	if q.Broken && !b.Health.Broken() {
+		return false
+	}
+	if q.MinPriority != 0 && b.Priority < q.MinPriority {
+		return false
+	}
+	if q.MaxPriority != 0 && b.Priority > q.MaxPriority {
+		return false
+	}
+	if !q.CreatedFrom.IsZero() && b.CreatedOn.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !b.CreatedOn.Before(q.CreatedTo) {
+		return false
+	}
+	if len(q.Tags) == 0 {
+		return true
+	}
+	found := 0
+	for _, tag := range q.Tags {
+		if hasTag(b, tag) {
+			found++
+		}
+	}
+	if q.TagMatch == store.AllTags {
+		return found == len(q.Tags)
+	}
+	return found > 0
+}
+
+func hasTag(b model.Bookmark, tag string) bool {
+	for _, t := range b.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// clone copies b so callers can't modify stored slices
+func clone(b model.Bookmark) model.Bookmark {
+	b.Tags = append([]string(nil), b.Tags...)
+	b.SharedWith = append([]string(nil), b.SharedWith...)
+	if b.Health != nil {
+		h := *b.Health
+		b.Health = &h
+	}
+	return b
+}
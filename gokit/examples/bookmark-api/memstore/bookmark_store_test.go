@@ -0,0 +1,119 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// seed stores bookmarks with priorities 1..5 created a minute apart
+func seed(t *testing.T, s *BookmarkStore, n int) {
+	t.Helper()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		b := model.Bookmark{Name: "b", Priority: i%5 + 1, Tags: []string{"go"}}
+		if i%2 == 0 {
+			b.Tags = append(b.Tags, "even")
+		}
+		if err := s.Create(context.Background(), &b); err != nil {
+			t.Fatal(err)
+		}
+		// Control creation times, including duplicates to exercise ID tie breaks
+		b.CreatedOn = start.Add(time.Duration(i/2) * time.Minute)
+		s.bookmarks[b.ID] = b
+	}
+}
+
+// listAll follows the Next tokens of q and returns every page
+func listAll(t *testing.T, s *BookmarkStore, q store.BookmarkQuery) [][]model.Bookmark {
+	t.Helper()
+	var pages [][]model.Bookmark
+	for {
+		page, err := s.List(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page.Bookmarks)
+		if page.Next == "" {
+			return pages
+		}
+		q.Cursor = page.Next
+	}
+}
+
+func TestBookmarkStore_ListPagination(t *testing.T) {
+	s := NewBookmarkStore()
+	seed(t, s, 23)
+	for _, sort := range []string{store.SortPriority, store.SortPriorityDesc, store.SortCreatedOn, store.SortCreatedOnDesc} {
+		q := store.BookmarkQuery{Sort: sort, Limit: 5}
+		pages := listAll(t, s, q)
+		if len(pages) != 5 || len(pages[4]) != 3 {
+			t.Errorf("%s: expected 5 pages of 5,5,5,5,3 bookmarks, got %d pages", sort, len(pages))
+		}
+		keys := q.SortKeys()
+		seen := map[string]bool{}
+		var prev *model.Bookmark
+		for _, page := range pages {
+			for i := range page {
+				b := page[i]
+				if seen[b.ID.Hex()] {
+					t.Errorf("%s: bookmark %s repeated", sort, b.ID.Hex())
+				}
+				seen[b.ID.Hex()] = true
+				if prev != nil && !store.Less(keys, *prev, b) {
+					t.Errorf("%s: bookmarks out of order: %+v before %+v", sort, *prev, b)
+				}
+				prev = &b
+			}
+		}
+		if len(seen) != 23 {
+			t.Errorf("%s: expected 23 bookmarks, got %d", sort, len(seen))
+		}
+	}
+}
+
+func TestBookmarkStore_ListFilters(t *testing.T) {
+	s := NewBookmarkStore()
+	seed(t, s, 10)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query store.BookmarkQuery
+		count int
+	}{
+		{"any tag", store.BookmarkQuery{Tags: []string{"even", "missing"}}, 5},
+		{"all tags", store.BookmarkQuery{Tags: []string{"go", "even"}, TagMatch: store.AllTags}, 5},
+		{"all tags missing", store.BookmarkQuery{Tags: []string{"go", "missing"}, TagMatch: store.AllTags}, 0},
+		{"priority range", store.BookmarkQuery{MinPriority: 2, MaxPriority: 3}, 4},
+		{"created range", store.BookmarkQuery{CreatedFrom: start.Add(time.Minute), CreatedTo: start.Add(3 * time.Minute)}, 4},
+	}
+	for _, tt := range tests {
+		page, err := s.List(context.Background(), tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(page.Bookmarks) != tt.count {
+			t.Errorf("%s: expected %d bookmarks, got %d", tt.name, tt.count, len(page.Bookmarks))
+		}
+	}
+}
+
+func TestBookmarkStore_ListInvalidCursor(t *testing.T) {
+	s := NewBookmarkStore()
+	seed(t, s, 3)
+	page, err := s.List(context.Background(), store.BookmarkQuery{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []store.BookmarkQuery{
+		{Cursor: "not a cursor"},
+		{Cursor: page.Next, Sort: store.SortCreatedOn}, // issued for another sort
+	} {
+		if _, err := s.List(context.Background(), q); err != store.ErrInvalidCursor {
+			t.Errorf("cursor %q: expected ErrInvalidCursor, got: %v", q.Cursor, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package memstore
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+var _ store.UserStore = (*UserStore)(nil)
+
+// UserStore is an in-memory store.UserStore keyed by email
+type UserStore struct {
+	mu    sync.RWMutex
+	users map[string]model.User
+}
+
+// NewUserStore returns an empty UserStore
+func NewUserStore() *UserStore {
+	return &UserStore{users: make(map[string]model.User)}
+}
+
+// Create stores user with a hash of password
+func (s *UserStore) Create(ctx context.Context, user model.User, password string) error {
+	hpass, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[user.Email]; ok {
+		return model.ErrEmailExists
+	}
+	user.ID = primitive.NewObjectID()
+	user.HashPassword = hpass
+	s.users[user.Email] = user
+	return nil
+}
+
+// Login returns the user if email and password match
+func (s *UserStore) Login(ctx context.Context, email, password string) (model.User, error) {
+	s.mu.RLock()
+	user, ok := s.users[email]
+	s.mu.RUnlock()
+	if !ok {
+		return model.User{}, model.ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword(user.HashPassword, []byte(password)); err != nil {
+		return model.User{}, model.ErrInvalidCredentials
+	}
+	return user, nil
+}
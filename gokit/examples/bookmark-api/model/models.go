@@ -1,29 +1,92 @@
 package model
 
 import (
+	"errors"
 	"time"
 
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrNotFound is returned when a document does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrEmailExists is returned when registering an email which is in use
+	ErrEmailExists = errors.New("email already registered")
+	// ErrInvalidCredentials is returned by Login for an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrForbidden is returned when a user may see a bookmark but not change it
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Visibility of a bookmark to users other than its owner
+const (
+	// Private bookmarks are only visible to their owner (the default)
+	Private = "private"
+	// Shared bookmarks are visible to the users in SharedWith
+	Shared = "shared"
+	// Public bookmarks are visible to every user
+	Public = "public"
 )
 
 type (
 	// User type represents the registered user.
 	User struct {
-		ID           bson.ObjectId `bson:"_id,omitempty" json:"id"`
-		FirstName    string        `json:"firstname"`
-		LastName     string        `json:"lastname"`
-		Email        string        `json:"email"`
-		HashPassword []byte        `json:"hashpassword,omitempty"`
+		ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+		FirstName    string             `json:"firstname"`
+		LastName     string             `json:"lastname"`
+		Email        string             `json:"email"`
+		HashPassword []byte             `json:"hashpassword,omitempty"`
 	}
 	// Bookmark type represents the metadata of a bookmark.
 	Bookmark struct {
-		ID          bson.ObjectId `bson:"_id,omitempty"`
-		Name        string        `json:"name"`
-		Description string        `json:"description"`
-		Location    string        `json:"location"`
-		Priority    int           `json:"priority"` // Priority (1 -5)
-		CreatedBy   string        `json:"createdby"`
-		CreatedOn   time.Time     `json:"createdon,omitempty"`
-		Tags        []string      `json:"tags,omitempty"`
+		ID          primitive.ObjectID `bson:"_id,omitempty"`
+		Name        string             `json:"name"`
+		Description string             `json:"description"`
+		Location    string             `json:"location"`
+		Priority    int                `json:"priority"` // Priority (1 -5)
+		CreatedBy   string             `json:"createdby"`
+		CreatedOn   time.Time          `json:"createdon,omitempty"`
+		Tags        []string           `json:"tags,omitempty"`
+		Visibility  string             `json:"visibility"`
+		SharedWith  []string           `json:"sharedwith,omitempty"`
+		Health      *LinkHealth        `json:"health,omitempty"` // nil until checked
+	}
+	// LinkHealth is the result of the last check of a bookmark's Location
+	LinkHealth struct {
+		Status    int       `json:"status"` // HTTP status, 0 if there was no response
+		Error     string    `json:"error,omitempty"`
+		FinalURL  string    `json:"finalurl,omitempty"` // Location after redirects
+		Title     string    `json:"title,omitempty"`
+		CheckedOn time.Time `json:"checkedon"`
 	}
 )
+
+// Broken reports whether the last check got no response or an error status
+func (h *LinkHealth) Broken() bool {
+	return h != nil && (h.Status == 0 || h.Status >= 400)
+}
+
+// ValidVisibility reports whether v is a known visibility; empty means Private
+func ValidVisibility(v string) bool {
+	return v == "" || v == Private || v == Shared || v == Public
+}
+
+// CanRead reports whether user may see b
+func (b Bookmark) CanRead(user string) bool {
+	if b.CanWrite(user) || b.Visibility == Public {
+		return true
+	}
+	if b.Visibility == Shared {
+		for _, u := range b.SharedWith {
+			if u == user {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanWrite reports whether user may change b; only its owner can
+func (b Bookmark) CanWrite(user string) bool {
+	return user != "" && b.CreatedBy == user
+}
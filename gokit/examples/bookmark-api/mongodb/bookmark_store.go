@@ -0,0 +1,359 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// BookmarkStore provides CRUD operations against the collection "bookmarks".
+type BookmarkStore struct {
+	C *mongo.Collection
+}
+
+// NewBookmarkStore returns a store.BookmarkStore on the "bookmarks" collection of db
+func NewBookmarkStore(db *mongo.Database) store.BookmarkStore {
+	return BookmarkStore{C: db.Collection("bookmarks")}
+}
+
+// Create inserts the value of struct Bookmark into collection.
+func (s BookmarkStore) Create(ctx context.Context, b *model.Bookmark) error {
+	b.ID = primitive.NewObjectID()
+	if b.CreatedOn.IsZero() {
+		b.CreatedOn = time.Now()
+	}
+	// MongoDB stores milliseconds; truncate so cursors match stored values
+	b.CreatedOn = b.CreatedOn.UTC().Truncate(time.Millisecond)
+	_, err := s.C.InsertOne(ctx, b)
+	return err
+}
+
+// Update modifies an existing document of a collection.
+func (s BookmarkStore) Update(ctx context.Context, b model.Bookmark) error {
+	// The last link check was of the old location, if it changed
+	_, err := s.C.UpdateOne(ctx,
+		bson.M{"_id": b.ID, "location": bson.M{"$ne": b.Location}},
+		bson.M{"$unset": bson.M{"health": ""}})
+	if err != nil {
+		return err
+	}
+	// partial update on MogoDB
+	result, err := s.C.UpdateByID(ctx, b.ID,
+		bson.M{"$set": bson.M{
+			"name":        b.Name,
+			"description": b.Description,
+			"location":    b.Location,
+			"priority":    b.Priority,
+			"tags":        b.Tags,
+		}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// SetSharing changes the visibility of an existing document.
+func (s BookmarkStore) SetSharing(ctx context.Context, id, visibility string, sharedWith []string) error {
+	oid, err := objectID(id)
+	if err != nil {
+		return err
+	}
+	if sharedWith == nil {
+		sharedWith = []string{}
+	}
+	result, err := s.C.UpdateByID(ctx, oid,
+		bson.M{"$set": bson.M{
+			"visibility": visibility,
+			"sharedwith": sharedWith,
+		}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes an existing document from the collection.
+func (s BookmarkStore) Delete(ctx context.Context, id string) error {
+	oid, err := objectID(id)
+	if err != nil {
+		return err
+	}
+	result, err := s.C.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// GetByID returns a single document from the collection.
+func (s BookmarkStore) GetByID(ctx context.Context, id string) (model.Bookmark, error) {
+	var b model.Bookmark
+	oid, err := objectID(id)
+	if err != nil {
+		return b, err
+	}
+	err = s.C.FindOne(ctx, bson.M{"_id": oid}).Decode(&b)
+	if err == mongo.ErrNoDocuments {
+		return b, model.ErrNotFound
+	}
+	return b, err
+}
+
+// List returns a page of the documents matching q, using the sort
+// fields of the last document as the cursor (keyset pagination)
+func (s BookmarkStore) List(ctx context.Context, q store.BookmarkQuery) (store.BookmarkPage, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return store.BookmarkPage{}, err
+	}
+	keys := q.SortKeys()
+	filter := queryFilter(q)
+	// The visibility, broken and cursor filters are all $or conditions
+	and := bson.A{}
+	if q.VisibleTo != "" {
+		and = append(and, bson.D{{Key: "$or", Value: visibleFilter(q.VisibleTo)}})
+	}
+	if q.Broken {
+		and = append(and, bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "health.status", Value: 0}},
+			bson.D{{Key: "health.status", Value: bson.M{"$gte": 400}}},
+		}}})
+	}
+	if q.Cursor != "" {
+		pos, err := store.DecodeCursor(q)
+		if err != nil {
+			return store.BookmarkPage{}, err
+		}
+		and = append(and, bson.D{{Key: "$or", Value: afterFilter(keys, pos)}})
+	}
+	if len(and) > 0 {
+		filter = append(filter, bson.E{Key: "$and", Value: and})
+	}
+	sort := bson.D{}
+	for _, k := range keys {
+		sort = append(sort, bson.E{Key: k.Field, Value: direction(k)})
+	}
+	// Fetch one extra document to know whether there is a next page
+	opts := options.Find().SetSort(sort).SetLimit(int64(q.Limit + 1))
+	cur, err := s.C.Find(ctx, filter, opts)
+	if err != nil {
+		return store.BookmarkPage{}, err
+	}
+	b := []model.Bookmark{}
+	if err := cur.All(ctx, &b); err != nil {
+		return store.BookmarkPage{}, err
+	}
+	page := store.BookmarkPage{Bookmarks: b}
+	if len(b) > q.Limit {
+		page.Bookmarks = b[:q.Limit]
+		page.Next = store.EncodeCursor(q.Sort, page.Bookmarks[q.Limit-1])
+	}
+	return page, nil
+}
+
+// ExistingLocations returns which of locations are used by documents of user
+func (s BookmarkStore) ExistingLocations(ctx context.Context, user string, locations []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(locations) == 0 {
+		return existing, nil
+	}
+	filter := bson.M{"createdby": user, "location": bson.M{"$in": locations}}
+	cur, err := s.C.Find(ctx, filter, options.Find().SetProjection(bson.M{"location": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var doc struct {
+			Location string `bson:"location"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		existing[doc.Location] = true
+	}
+	return existing, cur.Err()
+}
+
+// DueForCheck returns documents never checked or checked before the given time
+func (s BookmarkStore) DueForCheck(ctx context.Context, before time.Time, limit int) ([]model.Bookmark, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"health": nil},
+		bson.M{"health.checkedon": bson.M{"$lt": before}},
+	}}
+	// Documents without health sort first
+	opts := options.Find().
+		SetSort(bson.D{{Key: "health.checkedon", Value: 1}}).
+		SetLimit(int64(limit))
+	cur, err := s.C.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	b := []model.Bookmark{}
+	if err := cur.All(ctx, &b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// SetHealth records the result of a link check
+func (s BookmarkStore) SetHealth(ctx context.Context, id string, health model.LinkHealth) error {
+	oid, err := objectID(id)
+	if err != nil {
+		return err
+	}
+	result, err := s.C.UpdateByID(ctx, oid, bson.M{"$set": bson.M{"health": health}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// Search runs a $text query on the text index created by
+// EnsureSearchIndex, ordered by the MongoDB text score
+func (s BookmarkStore) Search(ctx context.Context, q store.SearchQuery) ([]store.SearchResult, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.D{{Key: "$text", Value: bson.M{"$search": q.Text}}}
+	if q.CreatedBy != "" {
+		filter = append(filter, bson.E{Key: "createdby", Value: q.CreatedBy})
+	}
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(q.Limit))
+	cur, err := s.C.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		model.Bookmark `bson:",inline"`
+		Score          float64 `bson:"score"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	results := make([]store.SearchResult, 0, len(docs))
+	for _, d := range docs {
+		results = append(results, store.SearchResult{
+			Bookmark:   d.Bookmark,
+			Score:      d.Score,
+			Highlights: store.Highlights(d.Bookmark, q.Text),
+		})
+	}
+	return results, nil
+}
+
+// queryFilter translates the filters of q
+func queryFilter(q store.BookmarkQuery) bson.D {
+	filter := bson.D{}
+	if q.CreatedBy != "" {
+		filter = append(filter, bson.E{Key: "createdby", Value: q.CreatedBy})
+	}
+	if len(q.Tags) > 0 {
+		op := "$in"
+		if q.TagMatch == store.AllTags {
+			op = "$all"
+		}
+		filter = append(filter, bson.E{Key: "tags", Value: bson.M{op: q.Tags}})
+	}
+	priority := bson.M{}
+	if q.MinPriority != 0 {
+		priority["$gte"] = q.MinPriority
+	}
+	if q.MaxPriority != 0 {
+		priority["$lte"] = q.MaxPriority
+	}
+	if len(priority) > 0 {
+		filter = append(filter, bson.E{Key: "priority", Value: priority})
+	}
+	created := bson.M{}
+	if !q.CreatedFrom.IsZero() {
+		created["$gte"] = q.CreatedFrom
+	}
+	if !q.CreatedTo.IsZero() {
+		created["$lt"] = q.CreatedTo
+	}
+	if len(created) > 0 {
+		filter = append(filter, bson.E{Key: "createdon", Value: created})
+	}
+	return filter
+}
+
+// visibleFilter matches the documents user may see, like model.Bookmark.CanRead
+func visibleFilter(user string) bson.A {
+	return bson.A{
+		bson.D{{Key: "createdby", Value: user}},
+		bson.D{{Key: "visibility", Value: model.Public}},
+		bson.D{{Key: "visibility", Value: model.Shared}, {Key: "sharedwith", Value: user}},
+	}
+}
+
+// afterFilter matches the documents sorting after pos: for sort keys
+// k1..kn, (k1 > v1) or (k1 = v1 and k2 > v2) and so on, where > is
+// $lt for descending keys
+func afterFilter(keys []store.SortKey, pos model.Bookmark) bson.A {
+	or := bson.A{}
+	for i, k := range keys {
+		clause := bson.D{}
+		for _, eq := range keys[:i] {
+			clause = append(clause, bson.E{Key: eq.Field, Value: sortValue(eq.Field, pos)})
+		}
+		op := "$gt"
+		if k.Desc {
+			op = "$lt"
+		}
+		clause = append(clause, bson.E{Key: k.Field, Value: bson.M{op: sortValue(k.Field, pos)}})
+		or = append(or, clause)
+	}
+	return or
+}
+
+func sortValue(field string, b model.Bookmark) interface{} {
+	switch field {
+	case "priority":
+		return b.Priority
+	case "createdon":
+		return b.CreatedOn
+	default:
+		return b.ID
+	}
+}
+
+func direction(k store.SortKey) int {
+	if k.Desc {
+		return -1
+	}
+	return 1
+}
+
+// objectID parses a hex id; malformed ids can't match any document
+func objectID(id string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return oid, model.ErrNotFound
+	}
+	return oid, nil
+}
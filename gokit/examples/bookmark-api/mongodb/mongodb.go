@@ -0,0 +1,75 @@
+// Package mongodb implements the bookmark-api stores on MongoDB
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// Config holds the settings used for connecting to MongoDB
+type Config struct {
+	Host, User, Password string
+	Timeout              time.Duration
+}
+
+// Connect creates a MongoDB client and checks the server is reachable
+func Connect(ctx context.Context, cfg Config) (*mongo.Client, error) {
+	opts := options.Client().
+		SetHosts([]string{cfg.Host}).
+		SetConnectTimeout(cfg.Timeout).
+		SetServerSelectionTimeout(cfg.Timeout)
+	if cfg.User != "" {
+		opts.SetAuth(options.Credential{Username: cfg.User, Password: cfg.Password})
+	}
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("pinging MongoDB: %w", err)
+	}
+	return client, nil
+}
+
+// EnsureIndexes creates the indexes used by the stores
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		return err
+	}
+	// Serves the default listing order, with or without a user filter
+	_, err = db.Collection("bookmarks").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "priority", Value: 1}, {Key: "createdon", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "createdby", Value: 1}, {Key: "priority", Value: 1}, {Key: "createdon", Value: -1}, {Key: "_id", Value: -1}}},
+		// Serves DueForCheck
+		{Keys: bson.D{{Key: "health.checkedon", Value: 1}}},
+	})
+	return err
+}
+
+// EnsureSearchIndex creates the text index used by BookmarkStore.Search,
+// weighted by store.SearchWeights
+func EnsureSearchIndex(ctx context.Context, db *mongo.Database) error {
+	keys := bson.D{}
+	weights := bson.D{}
+	for _, field := range []string{"name", "description", "location"} {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+		weights = append(weights, bson.E{Key: field, Value: store.SearchWeights[field]})
+	}
+	_, err := db.Collection("bookmarks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetName("bookmarks_text").SetWeights(weights),
+	})
+	return err
+}
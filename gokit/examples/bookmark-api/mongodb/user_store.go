@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
+)
+
+// UserStore provides persistence logic for "users" collection.
+type UserStore struct {
+	C *mongo.Collection
+}
+
+// NewUserStore returns a store.UserStore on the "users" collection of db
+func NewUserStore(db *mongo.Database) store.UserStore {
+	return UserStore{C: db.Collection("users")}
+}
+
+// Create insert new User
+func (s UserStore) Create(ctx context.Context, user model.User, password string) error {
+	user.ID = primitive.NewObjectID()
+	hpass, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.HashPassword = hpass
+	_, err = s.C.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return model.ErrEmailExists
+	}
+	return err
+}
+
+// Login authenticates the User
+func (s UserStore) Login(ctx context.Context, email, password string) (model.User, error) {
+	var user model.User
+	err := s.C.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.User{}, model.ErrInvalidCredentials
+		}
+		return model.User{}, err
+	}
+	// Validate password
+	err = bcrypt.CompareHashAndPassword(user.HashPassword, []byte(password))
+	if err != nil {
+		return model.User{}, model.ErrInvalidCredentials
+	}
+	return user, nil
+}
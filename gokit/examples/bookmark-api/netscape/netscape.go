@@ -0,0 +1,181 @@
+// Package netscape reads and writes the Netscape bookmark file format,
+// which browsers use to import and export bookmarks
+package netscape
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+)
+
+// Parse reads a Netscape bookmark file. The names of the folders
+// containing a bookmark become its tags, in addition to any TAGS
+// attribute. ADD_DATE, if present, is used as CreatedOn.
+func Parse(r io.Reader) ([]model.Bookmark, error) {
+	var (
+		z         = xhtml.NewTokenizer(r)
+		bookmarks []model.Bookmark
+		folders   []string // folder of each open <DL>, "" for none
+		pending   string   // folder of the <H3> waiting for its <DL>
+		current   *model.Bookmark
+		inDD      bool
+	)
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			if z.Err() == io.EOF {
+				return bookmarks, nil
+			}
+			return nil, fmt.Errorf("netscape: %w", z.Err())
+		case xhtml.StartTagToken:
+			tok := z.Token()
+			inDD = false
+			switch tok.DataAtom {
+			case atom.H3:
+				pending = strings.TrimSpace(text(z, atom.H3))
+			case atom.Dl:
+				folders = append(folders, pending)
+				pending = ""
+			case atom.A:
+				b := model.Bookmark{Location: strings.TrimSpace(attr(tok, "href"))}
+				if sec, err := strconv.ParseInt(attr(tok, "add_date"), 10, 64); err == nil && sec > 0 {
+					b.CreatedOn = time.Unix(sec, 0).UTC()
+				}
+				b.Tags = folderTags(folders)
+				for _, tag := range strings.Split(attr(tok, "tags"), ",") {
+					b.Tags = appendTag(b.Tags, tag)
+				}
+				b.Name = strings.TrimSpace(text(z, atom.A))
+				bookmarks = append(bookmarks, b)
+				current = &bookmarks[len(bookmarks)-1]
+			case atom.Dd:
+				inDD = current != nil
+			}
+		case xhtml.EndTagToken:
+			inDD = false
+			if tok := z.Token(); tok.DataAtom == atom.Dl && len(folders) > 0 {
+				folders = folders[:len(folders)-1]
+				current = nil
+			}
+		case xhtml.TextToken:
+			if inDD {
+				current.Description = strings.TrimSpace(current.Description + " " + strings.TrimSpace(string(z.Text())))
+			}
+		}
+	}
+}
+
+// text returns the text up to the end tag of a
+func text(z *xhtml.Tokenizer, a atom.Atom) string {
+	var b strings.Builder
+	for {
+		switch z.Next() {
+		case xhtml.ErrorToken:
+			return b.String()
+		case xhtml.TextToken:
+			b.Write(z.Text())
+		case xhtml.EndTagToken:
+			if z.Token().DataAtom == a {
+				return b.String()
+			}
+		}
+	}
+}
+
+func attr(tok xhtml.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func folderTags(folders []string) []string {
+	var tags []string
+	for _, f := range folders {
+		tags = appendTag(tags, f)
+	}
+	return tags
+}
+
+func appendTag(tags []string, tag string) []string {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return tags
+	}
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+const header = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`
+
+// Writer writes bookmarks as a flat Netscape bookmark file, with the
+// tags of each bookmark in its TAGS attribute
+type Writer struct {
+	w       *bufio.Writer
+	started bool
+}
+
+// NewWriter returns a Writer writing to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write writes a single bookmark
+func (w *Writer) Write(b model.Bookmark) error {
+	w.start()
+	fmt.Fprintf(w.w, `    <DT><A HREF="%s"`, html.EscapeString(b.Location))
+	if !b.CreatedOn.IsZero() {
+		fmt.Fprintf(w.w, ` ADD_DATE="%d"`, b.CreatedOn.Unix())
+	}
+	if len(b.Tags) > 0 {
+		fmt.Fprintf(w.w, ` TAGS="%s"`, html.EscapeString(strings.Join(b.Tags, ",")))
+	}
+	fmt.Fprintf(w.w, ">%s</A>\n", html.EscapeString(b.Name))
+	if b.Description != "" {
+		fmt.Fprintf(w.w, "    <DD>%s\n", html.EscapeString(b.Description))
+	}
+	return nil
+}
+
+// Flush writes buffered data to the underlying writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Close writes the end of the file and flushes it
+func (w *Writer) Close() error {
+	w.start()
+	w.w.WriteString("</DL><p>\n")
+	return w.w.Flush()
+}
+
+func (w *Writer) start() {
+	if !w.started {
+		w.w.WriteString(header)
+		w.started = true
+	}
+}
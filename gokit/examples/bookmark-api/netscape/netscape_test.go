@@ -0,0 +1,71 @@
+package netscape
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+)
+
+const browserExport = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1700000001">The Go Programming Language</A>
+        <DT><H3>Blogs</H3>
+        <DL><p>
+            <DT><A HREF="https://go.dev/blog" TAGS="news,go">Go Blog</A>
+            <DD>Posts &amp; announcements
+        </DL><p>
+    </DL><p>
+    <DT><A HREF="https://example.com">Example</A>
+</DL><p>
+`
+
+func TestParse(t *testing.T) {
+	got, err := Parse(strings.NewReader(browserExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.Bookmark{
+		{Name: "The Go Programming Language", Location: "https://go.dev/",
+			CreatedOn: time.Unix(1700000001, 0).UTC(), Tags: []string{"Bookmarks bar"}},
+		{Name: "Go Blog", Location: "https://go.dev/blog", Description: "Posts & announcements",
+			Tags: []string{"Bookmarks bar", "Blogs", "news", "go"}},
+		{Name: "Example", Location: "https://example.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse:\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	in := []model.Bookmark{
+		{Name: `A "quoted" <name>`, Location: "https://example.com/?a=1&b=2",
+			Description: "desc", CreatedOn: time.Unix(1700000000, 0).UTC(), Tags: []string{"x", "y"}},
+		{Name: "Plain", Location: "https://go.dev"},
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, b := range in {
+		if err := w.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip:\n got: %+v\nwant: %+v", out, in)
+	}
+}
@@ -8,14 +8,20 @@ import (
 )
 
 // SetBookmarkRoutes registers routes for bookmark entity.
-func SetBookmarkRoutes(router *mux.Router) *mux.Router {
+func SetBookmarkRoutes(router *mux.Router, handler controller.BookmarkController) *mux.Router {
 	bookmarkRouter := mux.NewRouter()
-	bookmarkRouter.HandleFunc("/bookmarks", controller.CreateBookmark).Methods("POST")
-	bookmarkRouter.HandleFunc("/bookmarks/{id}", controller.UpdateBookmark).Methods("PUT")
-	bookmarkRouter.HandleFunc("/bookmarks", controller.GetBookmarks).Methods("GET")
-	bookmarkRouter.HandleFunc("/bookmarks/{id}", controller.GetBookmarkByID).Methods("GET")
-	bookmarkRouter.HandleFunc("/bookmarks/users/{id}", controller.GetBookmarksByUser).Methods("GET")
-	bookmarkRouter.HandleFunc("/bookmarks/{id}", controller.DeleteBookmark).Methods("DELETE")
+	bookmarkRouter.HandleFunc("/bookmarks", handler.CreateBookmark).Methods("POST")
+	bookmarkRouter.HandleFunc("/bookmarks/{id}", handler.UpdateBookmark).Methods("PUT")
+	bookmarkRouter.HandleFunc("/bookmarks/{id}/sharing", handler.ShareBookmark).Methods("PUT")
+	bookmarkRouter.HandleFunc("/bookmarks", handler.GetBookmarks).Methods("GET")
+	// Registered before "/bookmarks/{id}", which would match them too
+	bookmarkRouter.HandleFunc("/bookmarks/search", handler.SearchBookmarks).Methods("GET")
+	bookmarkRouter.HandleFunc("/bookmarks/broken", handler.BrokenBookmarks).Methods("GET")
+	bookmarkRouter.HandleFunc("/bookmarks/export", handler.ExportBookmarks).Methods("GET")
+	bookmarkRouter.HandleFunc("/bookmarks/import", handler.ImportBookmarks).Methods("POST")
+	bookmarkRouter.HandleFunc("/bookmarks/{id}", handler.GetBookmarkByID).Methods("GET")
+	bookmarkRouter.HandleFunc("/bookmarks/users/{id}", handler.GetBookmarksByUser).Methods("GET")
+	bookmarkRouter.HandleFunc("/bookmarks/{id}", handler.DeleteBookmark).Methods("DELETE")
 	router.PathPrefix("/bookmarks").Handler(util.AuthorizeRequest(bookmarkRouter))
 	return router
 }
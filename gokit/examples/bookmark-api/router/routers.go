@@ -2,14 +2,17 @@ package router
 
 import (
 	"github.com/gorilla/mux"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/controller"
+	"github.com/shijuvar/gokit/examples/bookmark-api/store"
 )
 
 // InitRoutes registers all routes for the application.
-func InitRoutes() *mux.Router {
+func InitRoutes(bookmarks store.BookmarkStore, users store.UserStore) *mux.Router {
 	router := mux.NewRouter().StrictSlash(false)
 	// Routes for the User entity
-	router = SetUserRoutes(router)
+	router = SetUserRoutes(router, controller.UserController{Store: users})
 	// Routes for the Bookmark entity
-	router = SetBookmarkRoutes(router)
+	router = SetBookmarkRoutes(router, controller.BookmarkController{Store: bookmarks})
 	return router
 }
@@ -7,8 +7,8 @@ import (
 )
 
 // SetUserRoutes registers routes for user entity
-func SetUserRoutes(router *mux.Router) *mux.Router {
-	router.HandleFunc("/users", controller.Register).Methods("POST")
-	router.HandleFunc("/users/login", controller.Login).Methods("POST")
+func SetUserRoutes(router *mux.Router, handler controller.UserController) *mux.Router {
+	router.HandleFunc("/users", handler.Register).Methods("POST")
+	router.HandleFunc("/users/login", handler.Login).Methods("POST")
 	return router
 }
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SnippetLength is the approximate length, in runes, of a highlighted snippet
+const SnippetLength = 160
+
+// Highlight returns a snippet of text around the first word matching a
+// term of query, with matching words wrapped in <em></em>. A word
+// matches if it starts with a query term, so stemmed matches of a
+// MongoDB text search are highlighted as well. The text is HTML
+// escaped. It returns "" if nothing matches.
+func Highlight(text, query string) string {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return ""
+	}
+	runes := []rune(text)
+	type span struct{ start, end int }
+	var matches []span
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		if matchesAny(strings.ToLower(string(runes[i:j])), terms) {
+			matches = append(matches, span{i, j})
+		}
+		i = j
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	// Window of SnippetLength runes starting a little before the first match
+	start := matches[0].start - SnippetLength/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + SnippetLength
+	if end > len(runes) {
+		end = len(runes)
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m.start < start || m.end > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:m.start])))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(string(runes[m.start:m.end])))
+		b.WriteString("</em>")
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, t := range terms {
+		if strings.HasPrefix(word, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsNumber(r))
+}
@@ -0,0 +1,137 @@
+// Package search provides a small in-memory inverted index with TF-IDF
+// ranking and snippet highlighting. It is used where no MongoDB text
+// index is available, e.g. by the in-memory store.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopWords are ignored when indexing and searching
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+	"with": true,
+}
+
+// Tokenize splits text into lower case words, dropping stop words
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		if !stopWords[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// Field is a weighted piece of text of a document
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Hit is a matching document and its relevance score
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+// Index is an inverted index from terms to documents. It is not safe
+// for concurrent use; callers guard it with their own lock.
+type Index struct {
+	// postings holds the weighted term frequency per term and document
+	postings map[string]map[string]float64
+	// terms holds the indexed terms per document, for removal
+	terms map[string][]string
+}
+
+// NewIndex returns an empty Index
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[string]float64),
+		terms:    make(map[string][]string),
+	}
+}
+
+// Add indexes the fields of document id, replacing any previous version
+func (ix *Index) Add(id string, fields ...Field) {
+	ix.Remove(id)
+	freq := make(map[string]float64)
+	for _, f := range fields {
+		for _, t := range Tokenize(f.Text) {
+			freq[t] += f.Weight
+		}
+	}
+	terms := make([]string, 0, len(freq))
+	for t, tf := range freq {
+		docs, ok := ix.postings[t]
+		if !ok {
+			docs = make(map[string]float64)
+			ix.postings[t] = docs
+		}
+		docs[id] = tf
+		terms = append(terms, t)
+	}
+	ix.terms[id] = terms
+}
+
+// Remove drops document id from the index
+func (ix *Index) Remove(id string) {
+	for _, t := range ix.terms[id] {
+		delete(ix.postings[t], id)
+		if len(ix.postings[t]) == 0 {
+			delete(ix.postings, t)
+		}
+	}
+	delete(ix.terms, id)
+}
+
+// Search returns the documents matching any term of query, for which
+// keep returns true, ordered by descending TF-IDF score
+func (ix *Index) Search(query string, keep func(id string) bool) []Hit {
+	n := float64(len(ix.terms))
+	scores := make(map[string]float64)
+	for _, t := range uniq(Tokenize(query)) {
+		docs := ix.postings[t]
+		if len(docs) == 0 {
+			continue
+		}
+		idf := math.Log(1 + n/float64(len(docs)))
+		for id, tf := range docs {
+			scores[id] += tf * idf
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		if keep == nil || keep(id) {
+			hits = append(hits, Hit{ID: id, Score: score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits
+}
+
+func uniq(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package search
+
+import "testing"
+
+func TestIndex_Search(t *testing.T) {
+	ix := NewIndex()
+	ix.Add("1", Field{"Go concurrency patterns", 10}, Field{"Talk about goroutines", 5})
+	ix.Add("2", Field{"Rust book", 10}, Field{"Learn concurrency in Rust", 5})
+	ix.Add("3", Field{"Cooking", 10}, Field{"Recipes", 5})
+
+	hits := ix.Search("concurrency", nil)
+	if len(hits) != 2 || hits[0].ID != "1" || hits[1].ID != "2" {
+		t.Fatalf("expected the name match to rank first, got: %+v", hits)
+	}
+	hits = ix.Search("concurrency", func(id string) bool { return id != "1" })
+	if len(hits) != 1 || hits[0].ID != "2" {
+		t.Errorf("expected the filter to drop document 1, got: %+v", hits)
+	}
+
+	ix.Add("1", Field{"Go generics", 10})
+	ix.Remove("2")
+	if hits := ix.Search("concurrency", nil); len(hits) != 0 {
+		t.Errorf("expected no hits after update and remove, got: %+v", hits)
+	}
+	if hits := ix.Search("the of and", nil); len(hits) != 0 {
+		t.Errorf("expected stop words to match nothing, got: %+v", hits)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text, query, want string
+	}{
+		{"Go Concurrency Patterns", "concurrency", "Go <em>Concurrency</em> Patterns"},
+		{"Running <fast> code", "run code", "<em>Running</em> &lt;fast&gt; <em>code</em>"},
+		{"Nothing here", "go", ""},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text, tt.query); got != tt.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+)
+
+const (
+	// DefaultLimit is the page size used when a query sets no Limit
+	DefaultLimit = 20
+	// MaxLimit is the largest page size a query may ask for
+	MaxLimit = 100
+)
+
+// ErrInvalidCursor is returned for a malformed cursor or one issued for
+// a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TagMatch selects how BookmarkQuery.Tags are matched
+type TagMatch string
+
+const (
+	// AnyTag matches bookmarks having at least one of the tags
+	AnyTag TagMatch = "any"
+	// AllTags matches bookmarks having every tag
+	AllTags TagMatch = "all"
+)
+
+// Sort orders supported by BookmarkQuery. Ties are broken by ID, so
+// every order is total and cursors never skip or repeat a bookmark.
+const (
+	// SortPriority orders by priority and then newest first (the default)
+	SortPriority = "priority"
+	// SortPriorityDesc orders by priority descending and then newest first
+	SortPriorityDesc = "-priority"
+	// SortCreatedOn orders oldest first
+	SortCreatedOn = "createdon"
+	// SortCreatedOnDesc orders newest first
+	SortCreatedOnDesc = "-createdon"
+)
+
+// SortKey is a single field of a sort order
+type SortKey struct {
+	Field string // "priority", "createdon" or "_id"
+	Desc  bool
+}
+
+var sortOrders = map[string][]SortKey{
+	SortPriority:      {{"priority", false}, {"createdon", true}, {"_id", true}},
+	SortPriorityDesc:  {{"priority", true}, {"createdon", true}, {"_id", true}},
+	SortCreatedOn:     {{"createdon", false}, {"_id", false}},
+	SortCreatedOnDesc: {{"createdon", true}, {"_id", true}},
+}
+
+// BookmarkQuery selects a page of bookmarks. Zero values mean no filter.
+type BookmarkQuery struct {
+	CreatedBy string
+	// VisibleTo limits the results to the bookmarks this user may see
+	VisibleTo string
+	Tags      []string
+	TagMatch  TagMatch // AnyTag if empty
+	// MinPriority and MaxPriority bound the priority, both inclusive
+	MinPriority, MaxPriority int
+	// CreatedFrom is inclusive and CreatedTo exclusive
+	CreatedFrom, CreatedTo time.Time
+	Sort                   string // SortPriority if empty
+	Limit                  int    // DefaultLimit if zero
+	// Broken limits the results to bookmarks whose last link check failed
+	Broken bool
+	// Cursor is the Next token of the previous page
+	Cursor string
+}
+
+// BookmarkPage is a page of bookmarks. Next is empty on the last page.
+type BookmarkPage struct {
+	Bookmarks []model.Bookmark
+	Next      string
+}
+
+// Normalize applies the defaults and validates q
+func (q BookmarkQuery) Normalize() (BookmarkQuery, error) {
+	if q.Sort == "" {
+		q.Sort = SortPriority
+	}
+	if _, ok := sortOrders[q.Sort]; !ok {
+		return q, fmt.Errorf("unknown sort %q", q.Sort)
+	}
+	switch q.TagMatch {
+	case "":
+		q.TagMatch = AnyTag
+	case AnyTag, AllTags:
+	default:
+		return q, fmt.Errorf("unknown tag match %q", q.TagMatch)
+	}
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultLimit
+	case q.Limit < 0 || q.Limit > MaxLimit:
+		return q, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	if q.MaxPriority != 0 && q.MinPriority > q.MaxPriority {
+		return q, errors.New("minimum priority is greater than maximum priority")
+	}
+	if !q.CreatedTo.IsZero() && q.CreatedFrom.After(q.CreatedTo) {
+		return q, errors.New("created range starts after it ends")
+	}
+	return q, nil
+}
+
+// SortKeys returns the fields q is ordered by
+func (q BookmarkQuery) SortKeys() []SortKey {
+	if keys, ok := sortOrders[q.Sort]; ok {
+		return keys
+	}
+	return sortOrders[SortPriority]
+}
+
+// Less reports whether a comes before b in the order of keys
+func Less(keys []SortKey, a, b model.Bookmark) bool {
+	for _, k := range keys {
+		c := compare(k.Field, a, b)
+		if c == 0 {
+			continue
+		}
+		if k.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+func compare(field string, a, b model.Bookmark) int {
+	switch field {
+	case "priority":
+		return a.Priority - b.Priority
+	case "createdon":
+		return a.CreatedOn.Compare(b.CreatedOn)
+	default:
+		return bytes.Compare(a.ID[:], b.ID[:])
+	}
+}
+
+// cursor is the position after the last bookmark of a page
+type cursor struct {
+	Sort      string    `json:"s"`
+	Priority  int       `json:"p"`
+	CreatedOn time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// EncodeCursor returns an opaque token for the position after b
+func EncodeCursor(sort string, b model.Bookmark) string {
+	j, _ := json.Marshal(cursor{Sort: sort, Priority: b.Priority, CreatedOn: b.CreatedOn, ID: b.ID.Hex()})
+	return base64.RawURLEncoding.EncodeToString(j)
+}
+
+// DecodeCursor returns the position encoded in the Cursor of q as a
+// bookmark holding the sort fields, to be compared with Less
+func DecodeCursor(q BookmarkQuery) (model.Bookmark, error) {
+	var c cursor
+	j, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return model.Bookmark{}, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(j, &c); err != nil || c.Sort != q.Sort {
+		return model.Bookmark{}, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return model.Bookmark{}, ErrInvalidCursor
+	}
+	return model.Bookmark{ID: id, Priority: c.Priority, CreatedOn: c.CreatedOn}, nil
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+	"github.com/shijuvar/gokit/examples/bookmark-api/search"
+)
+
+// SearchWeights are the relative weights of the searchable fields
+var SearchWeights = map[string]int{
+	"name":        10,
+	"description": 5,
+	"location":    1,
+}
+
+// SearchQuery is a full-text search over name, description and location
+type SearchQuery struct {
+	Text      string
+	CreatedBy string // limits the search to the bookmarks of a user if set
+	Limit     int    // DefaultLimit if zero
+}
+
+// SearchResult is a bookmark matching a SearchQuery
+type SearchResult struct {
+	Bookmark model.Bookmark
+	Score    float64
+	// Highlights holds a snippet per matching field, with the matching
+	// words wrapped in <em></em>
+	Highlights map[string]string
+}
+
+// Normalize applies the defaults and validates q
+func (q SearchQuery) Normalize() (SearchQuery, error) {
+	if len(search.Tokenize(q.Text)) == 0 {
+		return q, errors.New("search text must contain at least one word")
+	}
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultLimit
+	case q.Limit < 0 || q.Limit > MaxLimit:
+		return q, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	return q, nil
+}
+
+// Highlights returns the snippets of the fields of b matching text
+func Highlights(b model.Bookmark, text string) map[string]string {
+	h := make(map[string]string)
+	for field, value := range map[string]string{
+		"name":        b.Name,
+		"description": b.Description,
+		"location":    b.Location,
+	} {
+		if snippet := search.Highlight(value, text); snippet != "" {
+			h[field] = snippet
+		}
+	}
+	return h
+}
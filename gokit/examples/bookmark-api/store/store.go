@@ -0,0 +1,45 @@
+// Package store defines the persistence contracts of bookmark-api.
+// Package mongodb implements them on MongoDB and package memstore in memory.
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/shijuvar/gokit/examples/bookmark-api/model"
+)
+
+// BookmarkStore provides CRUD operations for bookmarks.
+type BookmarkStore interface {
+	// Create assigns a new ID and inserts b. CreatedOn is set to the
+	// current time unless b has one, e.g. from an imported file.
+	Create(ctx context.Context, b *model.Bookmark) error
+	// Update modifies the name, description, location, priority and tags of b
+	Update(ctx context.Context, b model.Bookmark) error
+	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, id string) (model.Bookmark, error)
+	// List returns a page of the bookmarks matching q. It returns
+	// ErrInvalidCursor if q.Cursor was not issued for q.Sort.
+	List(ctx context.Context, q BookmarkQuery) (BookmarkPage, error)
+	// SetSharing changes the visibility of the bookmark and the users it
+	// is shared with
+	SetSharing(ctx context.Context, id, visibility string, sharedWith []string) error
+	// ExistingLocations returns which of locations are already used by
+	// bookmarks of user
+	ExistingLocations(ctx context.Context, user string, locations []string) (map[string]bool, error)
+	// DueForCheck returns up to limit bookmarks whose link was never
+	// checked or last checked before the given time, least recent first
+	DueForCheck(ctx context.Context, before time.Time, limit int) ([]model.Bookmark, error)
+	// SetHealth records the result of a link check
+	SetHealth(ctx context.Context, id string, health model.LinkHealth) error
+	// Search returns the bookmarks matching q, most relevant first
+	Search(ctx context.Context, q SearchQuery) ([]SearchResult, error)
+}
+
+// UserStore provides persistence logic for users.
+type UserStore interface {
+	// Create inserts user with a hash of password
+	Create(ctx context.Context, user model.User, password string) error
+	// Login returns the user if email and password match
+	Login(ctx context.Context, email, password string) (model.User, error)
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"github.com/rs/cors"
 
+	"github.com/shijuvar/gokit/log"
+	"github.com/shijuvar/gokit/server"
+
 	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/bootstrapper"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/middleware"
@@ -17,45 +18,59 @@ import (
 
 // Entry point of the program
 func main() {
+	// "appd migrate <command>" manages the database schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
 
 	// Calls startup logic
 	bootstrapper.StartUp()
+	// Creates a Postgres DB instance with an up to date schema
+	dataStore, err := bootstrapper.NewDataStore(util.AppConfig.AutoMigrate)
+	if err != nil {
+		util.Logger.Error("Error on connecting to database", "error", err)
+		log.Sync()
+		os.Exit(1)
+	}
 	// Get the mux router object
-	router := router.InitRoutes()
+	router := router.InitRoutes(dataStore)
+	// Expose request counters and latency histograms for Prometheus
+	metrics := middleware.NewMetrics()
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	// Liveness and readiness probes; ready when Postgres answers a ping
+	health := server.NewHealth()
+	health.AddCheck("postgres", dataStore.Ping)
+	router.Handle("/healthz", health.LivenessHandler()).Methods("GET")
+	router.Handle("/readyz", health.ReadinessHandler()).Methods("GET")
 	// cors.Default() setup the middleware with default options being
 	// all origins accepted with simple methods (GET, POST).
 	handler := cors.Default().Handler(router)
-	// Adding middleware handlers
+	// Adding middleware handlers, the last one runs first
 	handler = middleware.Apply(handler,
-		middleware.PanicRecovery(util.Error),
+		middleware.PanicRecovery(util.Logger),
 		middleware.RateLimiter(200),
-		)
+		metrics.Middleware(),
+		middleware.AccessLog(util.Logger),
+		middleware.RequestID(),
+	)
 	// Create the Server
-	server := &http.Server{
+	runner := server.NewRunner(&http.Server{
 		Addr:     util.AppConfig.Server,
 		Handler:  handler,
-		ErrorLog: util.Error,
+		ErrorLog: util.Logger.StdLogger(util.ERROR),
+	})
+	runner.Health = health
+	runner.Logger = util.Logger
+	if d := util.AppConfig.ShutdownTimeout; d > 0 {
+		runner.DrainTimeout = d
 	}
+	// Cleanup hooks run in order once in-flight requests are drained
+	runner.OnShutdown("postgres", dataStore.Close)
+	runner.OnShutdown("logs", func(ctx context.Context) error {
+		return log.Sync()
+	})
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, os.Kill, syscall.SIGTERM)
-
-	// Running the HTTP server
-	go func() {
-		server.ListenAndServe()
-	}()
-
-	interruptSignal := <-interrupt
-	switch interruptSignal {
-	case os.Kill:
-		util.Error.Print("Got SIGKILL...")
-	case os.Interrupt:
-		util.Error.Print("Got SIGINT...")
-	case syscall.SIGTERM:
-		util.Error.Print("Got SIGTERM...")
+	if err := runner.Run(); err != nil {
+		os.Exit(1)
 	}
-
-	util.Info.Print("The service is shutting down...")
-	server.Shutdown(context.Background())
-	util.Info.Print("Shut down is done")
 }
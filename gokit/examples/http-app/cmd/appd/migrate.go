@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shijuvar/gokit/examples/http-app/pkg/bootstrapper"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/postgres"
+)
+
+const migrateUsage = `usage: appd migrate <command>
+
+commands:
+  status  list migrations and whether they are applied
+  up      apply all pending migrations
+  down    roll back the most recently applied migration
+  redo    roll back and re-apply the most recently applied migration`
+
+var migrateCommands = map[string]bool{"status": true, "up": true, "down": true, "redo": true}
+
+// runMigrate runs the "migrate" subcommand and returns the exit code
+func runMigrate(args []string) int {
+	if len(args) != 1 || !migrateCommands[args[0]] {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	dataStore, err := bootstrapper.NewDataStore(false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer dataStore.Db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "status":
+		err = printMigrationStatus(ctx, dataStore)
+	case "up":
+		err = dataStore.MigrateUp(ctx)
+	case "down":
+		err = dataStore.MigrateDown(ctx)
+	case "redo":
+		err = dataStore.MigrateRedo(ctx)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, dataStore postgres.DataStore) error {
+	status, err := dataStore.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+	for _, m := range status {
+		appliedAt := "pending"
+		if m.Applied {
+			appliedAt = m.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\n", m.Version, m.Name, appliedAt)
+	}
+	return tw.Flush()
+}
@@ -1,11 +1,17 @@
 package apputil
 
+import "time"
+
 // configuration for app
 type Configuration struct {
-	Server   string // WebServer Host
-	LogLevel int    // Log Level: 0 - 4
+	Server       string // WebServer Host
+	SigningKeyID string // kid of the key in keys/ used to sign new tokens
+	LogLevel     int    // Log Level: 0 - 4
+	// How long in-flight requests may take on shutdown
+	ShutdownTimeout time.Duration
 	// Config for DataBase
 	DBHost, DBPort, DBUser, DBPassword, Database string
+	AutoMigrate                                  bool // Apply pending migrations on start up
 }
 
 // AppConfig holds the configurations used for web app
@@ -2,32 +2,158 @@ package apputil
 
 import (
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
+	"strings"
 )
 
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// FieldError describes why a single input field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// AppError is an error with everything needed to render an API error
+// response: a machine readable code, a message for clients, the HTTP
+// status and optional field level validation details
+type AppError struct {
+	Code    string
+	Message string
+	Status  int
+	Details []FieldError
+	// Err is the underlying error; it is only exposed for 4xx statuses
+	Err error
+}
+
+// NewAppError creates an AppError wrapping err
+func NewAppError(status int, code, message string, err error) *AppError {
+	return &AppError{Code: code, Message: message, Status: status, Err: err}
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// AsAppError returns the AppError in the chain of err, or wraps err
+// into one with the given status
+func AsAppError(err error, status int) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return NewAppError(status, CodeForStatus(status), http.StatusText(status), err)
+}
+
+// CodeForStatus returns a generic error code for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthenticated"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	}
+	if status >= 500 {
+		return "internal"
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
 type (
-	appError struct {
-		Error      string `json:"error"`
-		Message    string `json:"message"`
-		HTTPStatus int    `json:"status"`
+	// errorBody is the application/json error representation
+	errorBody struct {
+		Error   string       `json:"error"`
+		Code    string       `json:"code"`
+		Message string       `json:"message"`
+		Status  int          `json:"status"`
+		Details []FieldError `json:"details,omitempty"`
 	}
 	errorResource struct {
-		Data appError `json:"data"`
+		Data errorBody `json:"data"`
+	}
+	// problem is the application/problem+json representation (RFC 7807)
+	problem struct {
+		Type     string       `json:"type"`
+		Title    string       `json:"title"`
+		Status   int          `json:"status"`
+		Detail   string       `json:"detail"`
+		Instance string       `json:"instance,omitempty"`
+		Code     string       `json:"code"`
+		Errors   []FieldError `json:"errors,omitempty"`
 	}
 )
 
-// DisplayAppError provides app specific error in JSON
-func DisplayAppError(w http.ResponseWriter, handlerError error, message string, code int) {
-	errObj := appError{
-		Error:      handlerError.Error(),
-		Message:    message,
-		HTTPStatus: code,
-	}
-	//log.Printf("AppError]: %s\n", handlerError)
-	Error.Printf("AppError]: %s\n", handlerError)
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(code)
-	if j, err := json.Marshal(errorResource{Data: errObj}); err == nil {
+// WriteError writes appErr as JSON, or as problem details when the
+// client accepts application/problem+json. Server errors are logged
+// and their underlying error is not sent to the client.
+func WriteError(w http.ResponseWriter, r *http.Request, appErr *AppError) {
+	errText := ""
+	if appErr.Err != nil {
+		errText = appErr.Err.Error()
+	}
+	if appErr.Status >= 500 {
+		Logger.Error("AppError", "code", appErr.Code, "status", appErr.Status, "error", appErr.Err)
+		errText = http.StatusText(appErr.Status)
+	}
+
+	var body interface{}
+	if r != nil && acceptsProblem(r) {
+		w.Header().Set("Content-Type", ProblemContentType)
+		body = problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(appErr.Status),
+			Status:   appErr.Status,
+			Detail:   appErr.Message,
+			Instance: r.URL.Path,
+			Code:     appErr.Code,
+			Errors:   appErr.Details,
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		body = errorResource{Data: errorBody{
+			Error:   errText,
+			Code:    appErr.Code,
+			Message: appErr.Message,
+			Status:  appErr.Status,
+			Details: appErr.Details,
+		}}
+	}
+	w.WriteHeader(appErr.Status)
+	if j, err := json.Marshal(body); err == nil {
 		w.Write(j)
 	}
 }
+
+// acceptsProblem reports whether the Accept header of r lists
+// application/problem+json with a non-zero quality
+func acceptsProblem(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != ProblemContentType {
+			continue
+		}
+		if q := params["q"]; q == "0" || q == "0.0" || q == "0.00" || q == "0.000" {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -1,9 +1,9 @@
 package auth
 
 import (
-	"context"
-	"crypto/rsa"
-	"io/ioutil"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -12,67 +12,134 @@ import (
 	"github.com/dgrijalva/jwt-go/request"
 
 	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
 )
 
 // AppClaims provides custom claim for JWT
 type AppClaims struct {
-	UserName string `json:"username"`
-	Role     string `json:"role"`
+	UserName  string `json:"username"`
+	Role      string `json:"role"`
+	TokenType string `json:"typ,omitempty"`
 	jwt.StandardClaims
 }
 
+// Token types carried in the "typ" claim
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+// Token lifetimes
+const (
+	AccessTokenTTL  = 20 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 // using asymmetric crypto/RSA keys
-// location of private/public key files
+// location of private/public key files: keys/<kid>.rsa and keys/<kid>.rsa.pub
 const (
-	// openssl genrsa -out app.rsa 1024
-	privKeyPath = "keys/app.rsa"
-	// openssl rsa -in app.rsa -pubout > app.rsa.pub
-	pubKeyPath = "keys/app.rsa.pub"
+	// openssl genrsa -out keys/<kid>.rsa 2048
+	// openssl rsa -in keys/<kid>.rsa -pubout > keys/<kid>.rsa.pub
+	keysDir = "keys"
+	// defaultKeyID is the kid of the original keys/app.rsa key
+	defaultKeyID = "app"
 )
 
-// Private key for signing and public key for verification
 var (
-	//verifyKey, signKey []byte
-	verifyKey *rsa.PublicKey
-	signKey   *rsa.PrivateKey
+	// ErrTokenRevoked is returned for tokens on the revocation list
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrTokenType is returned when a refresh token is used as access token or vice versa
+	ErrTokenType = errors.New("unexpected token type")
+	// ErrNoToken is returned when a request carries no access token
+	ErrNoToken = request.ErrNoTokenInRequest
+	// ErrUnknownUser is returned when refreshing the tokens of a deleted user
+	ErrUnknownUser = errors.New("user no longer exists")
+)
+
+// Keys for signing and verification, and the list of revoked tokens
+var (
+	keys                   = NewKeySet()
+	revoked RevocationList = NewMemoryRevocationList()
 )
 
 // InitRSAKeys reads the key files to be used for JWT
 func InitRSAKeys() {
-
-	signBytes, err := ioutil.ReadFile(privKeyPath)
-	if err != nil {
-		log.Fatalf("[initKeys]: %s\n", err)
+	activeID := util.AppConfig.SigningKeyID
+	if activeID == "" {
+		activeID = defaultKeyID
 	}
-
-	signKey, err = jwt.ParseRSAPrivateKeyFromPEM(signBytes)
+	ks, err := LoadKeySet(keysDir, activeID)
 	if err != nil {
 		log.Fatalf("[initKeys]: %s\n", err)
 	}
+	keys = ks
+}
 
-	verifyBytes, err := ioutil.ReadFile(pubKeyPath)
+// SetKeySet replaces the keys used for signing and verification
+func SetKeySet(ks *KeySet) {
+	keys = ks
+}
+
+// SetRevocationList replaces the in-memory revocation list, e.g. with
+// one shared by all instances of the service
+func SetRevocationList(list RevocationList) {
+	revoked = list
+}
+
+// TokenPair is a short-lived access token with a refresh token to renew it
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// GenerateJWT generates a new JWT token for authenticated user.
+func GenerateJWT(name, role string) (string, error) {
+	return generateToken(name, role, AccessToken, AccessTokenTTL)
+}
+
+// GenerateTokenPair generates an access token and a refresh token
+func GenerateTokenPair(name, role string) (TokenPair, error) {
+	access, err := generateToken(name, role, AccessToken, AccessTokenTTL)
 	if err != nil {
-		log.Fatalf("[initKeys]: %s\n", err)
+		return TokenPair{}, err
 	}
-
-	verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
+	refresh, err := generateToken(name, role, RefreshToken, RefreshTokenTTL)
 	if err != nil {
-		log.Fatalf("[initKeys]: %s\n", err)
+		return TokenPair{}, err
 	}
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(AccessTokenTTL.Seconds()),
+	}, nil
 }
 
-// GenerateJWT generates a new JWT token for authenticated user.
-func GenerateJWT(name, role string) (string, error) {
+func generateToken(name, role, tokenType string, ttl time.Duration) (string, error) {
+	kid, signKey, err := keys.signingKey()
+	if err != nil {
+		return "", err
+	}
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	// Create the Claims
 	claims := AppClaims{
-		UserName: name,
-		Role:     role,
+		UserName:  name,
+		Role:      role,
+		TokenType: tokenType,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * 20).Unix(),
+			Id:        jti,
+			Subject:   name,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
 			Issuer:    "admin",
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
 	ss, err := token.SignedString(signKey)
 	if err != nil {
 		return "", err
@@ -80,65 +147,137 @@ func GenerateJWT(name, role string) (string, error) {
 	return ss, nil
 }
 
+// newTokenID returns a random value for the "jti" claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ParseToken validates the signature, expiry, type and revocation state
+// of tokenString and returns its claims
+func ParseToken(tokenString, tokenType string) (*AppClaims, error) {
+	claims := &AppClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc); err != nil {
+		return nil, err
+	}
+	if err := checkClaims(claims, tokenType); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims verifies the type and revocation state of validated claims
+func checkClaims(claims *AppClaims, tokenType string) error {
+	// Tokens issued before refresh tokens existed carry no type
+	typ := claims.TokenType
+	if typ == "" {
+		typ = AccessToken
+	}
+	if typ != tokenType {
+		return ErrTokenType
+	}
+	isRevoked, err := revoked.IsRevoked(claims.Id)
+	if err != nil {
+		return err
+	}
+	if isRevoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// RefreshTokens exchanges a valid refresh token for a new token pair.
+// The refresh token is revoked, so it can be used only once: of concurrent
+// requests with the same token only the one which revokes it succeeds.
+// The new tokens carry the current role of the user, read from users.
+func RefreshTokens(refreshToken string, users domain.UserStore) (TokenPair, error) {
+	claims, err := ParseToken(refreshToken, RefreshToken)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	user, err := users.GetByEmail(claims.UserName)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return TokenPair{}, ErrUnknownUser
+		}
+		return TokenPair{}, err
+	}
+	ok, err := revoked.Revoke(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if !ok {
+		return TokenPair{}, ErrTokenRevoked
+	}
+	return GenerateTokenPair(user.Email, user.Role)
+}
+
+// RevokeClaims puts the token described by claims on the revocation list.
+// Revoking a token twice is not an error.
+func RevokeClaims(claims *AppClaims) error {
+	_, err := revoked.Revoke(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	return err
+}
+
+// ParseRequestToken validates the access token sent as Bearer token with r
+// and returns its claims. It returns ErrNoToken if r carries none.
+func ParseRequestToken(r *http.Request) (*AppClaims, error) {
+	claims := &AppClaims{}
+	_, err := request.ParseFromRequest(
+		r,
+		request.OAuth2Extractor,
+		keys.Keyfunc,
+		request.WithClaims(claims),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkClaims(claims, AccessToken); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// IsAuthError reports whether err means the client sent a bad token
+// rather than the server failing to check it
+func IsAuthError(err error) bool {
+	var vErr *jwt.ValidationError
+	return errors.As(err, &vErr) ||
+		errors.Is(err, ErrNoToken) ||
+		errors.Is(err, ErrTokenRevoked) ||
+		errors.Is(err, ErrTokenType) ||
+		errors.Is(err, ErrUnknownUser)
+}
+
 // AuthorizeRequest Middleware validates JWT tokens from incoming HTTP requests.
 func AuthorizeRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get token from request
-		token, err := request.ParseFromRequest(
-			r,
-			request.OAuth2Extractor,
-			func(token *jwt.Token) (interface{}, error) {
-				return verifyKey, nil
-			},
-			request.WithClaims(&AppClaims{}),
-		)
-
+		claims, err := ParseRequestToken(r)
 		if err != nil {
-			switch err.(type) {
-
-			case *jwt.ValidationError: // JWT validation error
-				vErr := err.(*jwt.ValidationError)
-
-				switch vErr.Errors {
-				case jwt.ValidationErrorExpired: //JWT expired
-					util.DisplayAppError(
-						w,
-						err,
-						"Access Token is expired, get a new Token",
-						401,
-					)
-					return
-
-				default:
-					util.DisplayAppError(w,
-						err,
-						"Error while parsing the Access Token!",
-						500,
-					)
-					return
-				}
-
+			var vErr *jwt.ValidationError
+			switch {
+			case errors.As(err, &vErr) && vErr.Errors&jwt.ValidationErrorExpired != 0: //JWT expired
+				util.WriteError(w, r, util.NewAppError(http.StatusUnauthorized,
+					"token_expired", "Access Token is expired, get a new Token", err))
+			case errors.Is(err, ErrTokenRevoked):
+				util.WriteError(w, r, util.NewAppError(http.StatusUnauthorized,
+					"token_revoked", "Access Token has been revoked", err))
+			case IsAuthError(err):
+				util.WriteError(w, r, util.NewAppError(http.StatusUnauthorized,
+					"invalid_token", "Invalid Access Token", err))
 			default:
-				util.DisplayAppError(w,
-					err,
-					"Error while parsing Access Token!",
-					500)
-				return
+				util.WriteError(w, r, util.NewAppError(http.StatusInternalServerError,
+					"internal", "Error while validating Access Token!", err))
 			}
-
-		}
-		if token.Valid {
-			// Create a Context by setting the user name
-			ctx := context.WithValue(r.Context(), "user", token.Claims.(*AppClaims).UserName)
-			// Calls the next handler by providing the Context
-			next.ServeHTTP(w, r.WithContext(ctx))
-		} else {
-			util.DisplayAppError(
-				w,
-				err,
-				"Invalid Access Token",
-				401,
-			)
+			return
 		}
+		// Create a Context by setting the authenticated principal
+		ctx := NewContext(r.Context(), Principal{UserName: claims.UserName, Role: claims.Role})
+		// Calls the next handler by providing the Context
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+)
+
+// setUpKeys installs a fresh KeySet with a single "k1" signing key and
+// an empty revocation list
+func setUpKeys(t *testing.T) *KeySet {
+	t.Helper()
+	ks := NewKeySet()
+	ks.AddSigningKey("k1", newRSAKey(t))
+	if err := ks.SetActive("k1"); err != nil {
+		t.Fatal(err)
+	}
+	SetKeySet(ks)
+	SetRevocationList(NewMemoryRevocationList())
+	return ks
+}
+
+// userRoles is a domain.UserStore of users and their roles, by email
+type userRoles map[string]string
+
+func (u userRoles) Create(user domain.User, password string) (domain.User, error) {
+	u[user.Email] = user.Role
+	return user, nil
+}
+
+func (u userRoles) Login(email, password string) (domain.User, error) {
+	return u.GetByEmail(email)
+}
+
+func (u userRoles) GetByEmail(email string) (domain.User, error) {
+	role, ok := u[email]
+	if !ok {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return domain.User{Email: email, Role: role}, nil
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func authorize(token string) int {
+	h := AuthorizeRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest("GET", "/products", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuthorizeRequest(t *testing.T) {
+	setUpKeys(t)
+	pair, err := GenerateTokenPair("gopher@example.com", "member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"access token", pair.AccessToken, http.StatusOK},
+		{"no token", "", http.StatusUnauthorized},
+		{"malformed token", "not-a-jwt", http.StatusUnauthorized},
+		{"refresh token", pair.RefreshToken, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := authorize(tt.token); got != tt.status {
+				t.Errorf("HTTP Status expected: %d, got: %d", tt.status, got)
+			}
+		})
+	}
+}
+
+func TestAuthorizeRequest_Revoked(t *testing.T) {
+	setUpKeys(t)
+	token, _ := GenerateJWT("gopher@example.com", "member")
+	claims, err := ParseToken(token, AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeClaims(claims); err != nil {
+		t.Fatal(err)
+	}
+	if got := authorize(token); got != http.StatusUnauthorized {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusUnauthorized, got)
+	}
+}
+
+func TestRefreshTokens_SingleUse(t *testing.T) {
+	setUpKeys(t)
+	users := userRoles{"gopher@example.com": "admin"}
+	pair, _ := GenerateTokenPair("gopher@example.com", "admin")
+
+	next, err := RefreshTokens(pair.RefreshToken, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(next.AccessToken, AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserName != "gopher@example.com" || claims.Role != "admin" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if _, err := RefreshTokens(pair.RefreshToken, users); !IsAuthError(err) {
+		t.Errorf("expected reused refresh token to be rejected, got %v", err)
+	}
+	if _, err := RefreshTokens(next.AccessToken, users); !IsAuthError(err) {
+		t.Errorf("expected access token to be rejected as refresh token, got %v", err)
+	}
+}
+
+func TestRefreshTokens_CurrentRole(t *testing.T) {
+	setUpKeys(t)
+	users := userRoles{"gopher@example.com": domain.RoleAdmin}
+	pair, _ := GenerateTokenPair("gopher@example.com", domain.RoleAdmin)
+
+	// Demoted between login and refresh
+	users["gopher@example.com"] = domain.RoleMember
+	next, err := RefreshTokens(pair.RefreshToken, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for token, typ := range map[string]string{next.AccessToken: AccessToken, next.RefreshToken: RefreshToken} {
+		claims, err := ParseToken(token, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.Role != domain.RoleMember {
+			t.Errorf("expected role %q in the %s token, got %q", domain.RoleMember, typ, claims.Role)
+		}
+	}
+
+	// Deleted after the refresh
+	delete(users, "gopher@example.com")
+	if _, err := RefreshTokens(next.RefreshToken, users); !errors.Is(err, ErrUnknownUser) || !IsAuthError(err) {
+		t.Errorf("expected ErrUnknownUser, got %v", err)
+	}
+}
+
+func TestRefreshTokens_Concurrent(t *testing.T) {
+	setUpKeys(t)
+	users := userRoles{"gopher@example.com": "member"}
+	pair, _ := GenerateTokenPair("gopher@example.com", "member")
+
+	const n = 20
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := RefreshTokens(pair.RefreshToken, users)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrTokenRevoked):
+			t.Errorf("expected ErrTokenRevoked, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one refresh to succeed, got %d", succeeded)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ks := setUpKeys(t)
+	oldToken, _ := GenerateJWT("gopher@example.com", "member")
+
+	// Roll out k2 and keep only the public part of k1
+	old := ks.signKeys["k1"]
+	rotated := NewKeySet()
+	rotated.AddVerifyKey("k1", &old.PublicKey)
+	rotated.AddSigningKey("k2", newRSAKey(t))
+	rotated.SetActive("k2")
+	SetKeySet(rotated)
+
+	newToken, _ := GenerateJWT("gopher@example.com", "member")
+	for _, token := range []string{oldToken, newToken} {
+		if got := authorize(token); got != http.StatusOK {
+			t.Errorf("HTTP Status expected: %d, got: %d", http.StatusOK, got)
+		}
+	}
+	if err := rotated.SetActive("k1"); err == nil {
+		t.Error("expected a verification-only key not to become active")
+	}
+	if jwks := rotated.JWKS()["keys"]; len(jwks) != 2 || jwks[0].Kid != "k1" || jwks[1].Kid != "k2" {
+		t.Errorf("unexpected JWKS: %+v", jwks)
+	}
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// KeySet holds the RSA keys used for JWT, identified by key id ("kid").
+// Tokens are signed with the active key and verified with any key in the
+// set, so a new key can be rolled out while tokens signed with the old
+// one are still accepted.
+type KeySet struct {
+	mu       sync.RWMutex
+	activeID string
+	signKeys map[string]*rsa.PrivateKey
+	pubKeys  map[string]*rsa.PublicKey
+}
+
+// NewKeySet returns an empty KeySet
+func NewKeySet() *KeySet {
+	return &KeySet{
+		signKeys: make(map[string]*rsa.PrivateKey),
+		pubKeys:  make(map[string]*rsa.PublicKey),
+	}
+}
+
+// AddSigningKey adds a private key, whose public part is used for verification
+func (ks *KeySet) AddSigningKey(kid string, key *rsa.PrivateKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.signKeys[kid] = key
+	ks.pubKeys[kid] = &key.PublicKey
+}
+
+// AddVerifyKey adds a public key, e.g. of a retired signing key
+func (ks *KeySet) AddVerifyKey(kid string, key *rsa.PublicKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.pubKeys[kid] = key
+}
+
+// SetActive selects the signing key used for new tokens
+func (ks *KeySet) SetActive(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.signKeys[kid]; !ok {
+		return fmt.Errorf("no private key with kid %q", kid)
+	}
+	ks.activeID = kid
+	return nil
+}
+
+// signingKey returns the active key id and private key
+func (ks *KeySet) signingKey() (string, *rsa.PrivateKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.signKeys[ks.activeID]
+	if !ok {
+		return "", nil, fmt.Errorf("no active signing key")
+	}
+	return ks.activeID, key, nil
+}
+
+// verifyKey returns the public key for kid. Tokens without a kid, issued
+// before key rotation was introduced, are verified with the active key.
+func (ks *KeySet) verifyKey(kid string) (*rsa.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if kid == "" {
+		kid = ks.activeID
+	}
+	key, ok := ks.pubKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	return key, nil
+}
+
+// Keyfunc is a jwt.Keyfunc which picks the verification key by the
+// "kid" header of the token
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	return ks.verifyKey(kid)
+}
+
+// LoadKeySet reads all keys from dir. Each <kid>.rsa file is a signing
+// key and each <kid>.rsa.pub without a matching private key is a
+// verification-only key.
+func LoadKeySet(dir, activeID string) (*KeySet, error) {
+	ks := NewKeySet()
+	privFiles, err := filepath.Glob(filepath.Join(dir, "*.rsa"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range privFiles {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		ks.AddSigningKey(strings.TrimSuffix(filepath.Base(file), ".rsa"), key)
+	}
+	pubFiles, err := filepath.Glob(filepath.Join(dir, "*.rsa.pub"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range pubFiles {
+		kid := strings.TrimSuffix(filepath.Base(file), ".rsa.pub")
+		if _, ok := ks.signKeys[kid]; ok {
+			continue
+		}
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		ks.AddVerifyKey(kid, key)
+	}
+	if err := ks.SetActive(activeID); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// jwk is a JSON Web Key for an RSA public key (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS returns the public keys of the set as a JSON Web Key Set
+func (ks *KeySet) JWKS() map[string][]jwk {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	keys := make([]jwk, 0, len(ks.pubKeys))
+	for kid, key := range ks.pubKeys {
+		keys = append(keys, jwk{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return map[string][]jwk{"keys": keys}
+}
+
+// JWKSHandler serves the public keys for other services to verify tokens
+// Handler for HTTP Get - "/.well-known/jwks.json"
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(keys.JWKS())
+}
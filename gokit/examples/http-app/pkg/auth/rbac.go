@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserName string
+	Role     string
+}
+
+// Permission is an action a role may perform
+type Permission string
+
+// Permissions checked by the routes
+const (
+	ReadProducts  Permission = "products:read"
+	WriteProducts Permission = "products:write"
+)
+
+// rolePermissions grants permissions to each role
+var rolePermissions = map[string][]Permission{
+	domain.RoleAdmin:  {ReadProducts, WriteProducts},
+	domain.RoleMember: {ReadProducts},
+}
+
+// HasPermission reports whether the role of p grants perm
+func (p Principal) HasPermission(perm Permission) bool {
+	for _, granted := range rolePermissions[p.Role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// contextKey is unexported to avoid collisions with keys of other packages
+type contextKey int
+
+const principalKey contextKey = iota
+
+// NewContext returns a copy of ctx carrying p
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns the Principal stored by AuthorizeRequest
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
+
+var (
+	errNoPrincipal = errors.New("request is not authenticated")
+	errForbidden   = errors.New("principal is not allowed to access the resource")
+)
+
+// RequireRole Middleware allows the request only if the principal has one of roles.
+// It must run after AuthorizeRequest.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return requirePrincipal(func(p Principal) bool {
+		for _, role := range roles {
+			if p.Role == role {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RequirePermission Middleware allows the request only if the role of the
+// principal grants all of perms. It must run after AuthorizeRequest.
+func RequirePermission(perms ...Permission) func(http.Handler) http.Handler {
+	return requirePrincipal(func(p Principal) bool {
+		for _, perm := range perms {
+			if !p.HasPermission(perm) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func requirePrincipal(allowed func(Principal) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				util.WriteError(w, r, util.NewAppError(http.StatusUnauthorized,
+					"unauthenticated", "Access Token is required", errNoPrincipal))
+				return
+			}
+			if !allowed(p) {
+				util.WriteError(w, r, util.NewAppError(http.StatusForbidden,
+					"forbidden", "Insufficient permissions", errForbidden))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
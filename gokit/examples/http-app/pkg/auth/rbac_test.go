@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+)
+
+func TestRequirePermission(t *testing.T) {
+	setUpKeys(t)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := PrincipalFromContext(r.Context())
+		if p.UserName == "" {
+			t.Error("expected a principal in the context")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	read := AuthorizeRequest(RequirePermission(ReadProducts)(ok))
+	write := AuthorizeRequest(RequirePermission(WriteProducts)(ok))
+	adminOnly := AuthorizeRequest(RequireRole(domain.RoleAdmin)(ok))
+
+	member, _ := GenerateJWT("member@example.com", domain.RoleMember)
+	admin, _ := GenerateJWT("admin@example.com", domain.RoleAdmin)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		token   string
+		status  int
+	}{
+		{"member reads", read, member, http.StatusOK},
+		{"member writes", write, member, http.StatusForbidden},
+		{"admin writes", write, admin, http.StatusOK},
+		{"member on admin role", adminOnly, member, http.StatusForbidden},
+		{"admin on admin role", adminOnly, admin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/products", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("HTTP Status expected: %d, got: %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireRole_WithoutPrincipal(t *testing.T) {
+	h := RequireRole(domain.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/products", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationList records revoked tokens by their "jti" claim until they expire.
+// Revoke reports whether jti was newly revoked, so that of concurrent
+// revocations of the same token exactly one succeeds.
+type RevocationList interface {
+	Revoke(jti string, expiresAt time.Time) (bool, error)
+	IsRevoked(jti string) (bool, error)
+}
+
+// MemoryRevocationList is an in-memory RevocationList for a single instance
+type MemoryRevocationList struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryRevocationList returns an empty MemoryRevocationList
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{revoked: make(map[string]time.Time)}
+}
+
+// Revoke adds jti to the list and drops entries which have expired.
+// It reports false if jti was already on the list.
+func (l *MemoryRevocationList) Revoke(jti string, expiresAt time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for id, exp := range l.revoked {
+		if exp.Before(now) {
+			delete(l.revoked, id)
+		}
+	}
+	if _, ok := l.revoked[jti]; ok {
+		return false, nil
+	}
+	l.revoked[jti] = expiresAt
+	return true, nil
+}
+
+// IsRevoked reports whether jti has been revoked
+func (l *MemoryRevocationList) IsRevoked(jti string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.revoked[jti]
+	return ok, nil
+}
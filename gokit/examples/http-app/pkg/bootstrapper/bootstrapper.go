@@ -2,12 +2,14 @@
 package bootstrapper
 
 import (
+	"context"
 	"log"
 
 	"github.com/spf13/viper"
 
 	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/postgres"
 )
 
 // StartUp bootstrapps the application
@@ -21,6 +23,28 @@ func StartUp() {
 
 }
 
+// NewDataStore creates a Postgres DataStore from AppConfig and applies
+// pending schema migrations when migrate is true
+func NewDataStore(migrate bool) (postgres.DataStore, error) {
+	config := postgres.Config{
+		Host:     util.AppConfig.DBHost,
+		Port:     util.AppConfig.DBPort,
+		User:     util.AppConfig.DBUser,
+		Password: util.AppConfig.DBPassword,
+		Database: util.AppConfig.Database,
+	}
+	dataStore, err := postgres.New(config)
+	if err != nil {
+		return dataStore, err
+	}
+	if migrate {
+		if err := dataStore.MigrateUp(context.Background()); err != nil {
+			return dataStore, err
+		}
+	}
+	return dataStore, nil
+}
+
 // loadAppConfig reads the config file app.toml and create AppConfig instance
 func init() {
 	viper.SetConfigName("app")
@@ -34,6 +58,8 @@ func init() {
 	// Configure app specific config values
 	util.AppConfig.Server = viper.GetString("devserver.Server")
 	util.AppConfig.LogLevel = viper.GetInt("devserver.LogLevel")
+	util.AppConfig.ShutdownTimeout = viper.GetDuration("devserver.ShutdownTimeout")
+	util.AppConfig.SigningKeyID = viper.GetString("auth.SigningKeyID")
 
 	// Configure Postgres configuration values
 	util.AppConfig.DBHost = viper.GetString("postgres.Host")
@@ -41,5 +67,6 @@ func init() {
 	util.AppConfig.DBUser = viper.GetString("postgres.User")
 	util.AppConfig.DBPassword = viper.GetString("postgres.Password")
 	util.AppConfig.Database = viper.GetString("postgres.Database")
+	util.AppConfig.AutoMigrate = viper.GetBool("postgres.AutoMigrate")
 
 }
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+)
+
+// domainErrors maps domain errors to the API error returned to clients
+var domainErrors = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{domain.ErrProductNotFound, http.StatusNotFound, "product_not_found", "Product not found"},
+	{domain.ErrDuplicateSKU, http.StatusConflict, "duplicate_sku", "A product with this SKU already exists"},
+	{domain.ErrValidation, http.StatusBadRequest, "validation_failed", "Validation failed"},
+	{domain.ErrInvalidProduct, http.StatusBadRequest, "invalid_product", "Invalid product"},
+}
+
+// toAppError converts a handler error into an AppError. AppErrors are
+// kept as they are, known domain errors get their own status and code,
+// and anything else gets the status returned by the handler.
+func toAppError(err error, status int) *util.AppError {
+	var appErr *util.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	for _, de := range domainErrors {
+		if errors.Is(err, de.err) {
+			appErr = util.NewAppError(de.status, de.code, de.message, err)
+			break
+		}
+	}
+	if appErr == nil {
+		if status < 400 {
+			status = http.StatusInternalServerError
+		}
+		appErr = util.AsAppError(err, status)
+	}
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		for _, f := range verr.Fields {
+			appErr.Details = append(appErr.Details, util.FieldError{Field: f.Field, Message: f.Message})
+		}
+	}
+	return appErr
+}
@@ -12,12 +12,15 @@ type response struct {
 	Data interface{} `json:"data"`
 }
 
-// Generic handler for writing response header and body for all handler functions
+// Generic handler for writing response header and body for all handler functions.
+// Errors are mapped to an AppError and written in the uniform error format,
+// or as RFC 7807 problem details if the client accepts them.
 func ResponseHandler(h func(http.ResponseWriter, *http.Request) (interface{}, int, error)) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		data, status, err := h(w, r) // execute application handler
 		if err != nil {
-			data = err.Error()
+			util.WriteError(w, r, toAppError(err, status))
+			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(status)
@@ -25,7 +28,7 @@ func ResponseHandler(h func(http.ResponseWriter, *http.Request) (interface{}, in
 			// Send JSON response back to the client application
 			err = json.NewEncoder(w).Encode(response{Data: data})
 			if err != nil {
-				util.Error.Printf("Error from Handler: %s\n", err.Error())
+				util.Logger.Error("Error from Handler", "error", err)
 			}
 		}
 
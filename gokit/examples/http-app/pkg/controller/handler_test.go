@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
+)
+
+func TestResponseHandler_ValidationError(t *testing.T) {
+	r, _ := setUpProductRouter(t)
+	w := serve(r, "POST", "/products", `{"sku": "SKU-1", "discountPerc": 120}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("unexpected Content-Type: %q", ct)
+	}
+	var resp struct {
+		Data struct {
+			Code    string
+			Status  int
+			Details []util.FieldError
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Code != "validation_failed" || resp.Data.Status != http.StatusBadRequest {
+		t.Errorf("unexpected error: %+v", resp.Data)
+	}
+	fields := map[string]bool{}
+	for _, d := range resp.Data.Details {
+		fields[d.Field] = true
+	}
+	if !fields["name"] || !fields["discountPerc"] {
+		t.Errorf("expected details for name and discountPerc, got: %+v", resp.Data.Details)
+	}
+}
+
+func TestResponseHandler_ProblemDetails(t *testing.T) {
+	r, _ := setUpProductRouter(t)
+	req := httptest.NewRequest("GET", "/products/99", nil)
+	req.Header.Set("Accept", "application/problem+json, application/json;q=0.5")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != util.ProblemContentType {
+		t.Errorf("Content-Type expected: %q, got: %q", util.ProblemContentType, ct)
+	}
+	var p struct {
+		Type     string
+		Title    string
+		Status   int
+		Instance string
+		Code     string
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != http.StatusNotFound || p.Code != "product_not_found" ||
+		p.Instance != "/products/99" || p.Type != "about:blank" {
+		t.Errorf("unexpected problem: %+v", p)
+	}
+}
+
+func TestResponseHandler_ServerErrorHidesCause(t *testing.T) {
+	h := ResponseHandler(func(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+		return nil, http.StatusInternalServerError, errSecret
+	})
+	w := serve(h, "GET", "/", "")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusInternalServerError, w.Code)
+	}
+	if strings.Contains(w.Body.String(), errSecret.Error()) {
+		t.Errorf("response leaks the underlying error: %s", w.Body.String())
+	}
+}
+
+var errSecret = errors.New("dial tcp 10.0.0.1:5432: connection refused")
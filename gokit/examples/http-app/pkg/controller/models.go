@@ -1,6 +1,9 @@
 package controller
 
-import "github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+import (
+	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+)
 
 // Data models used for API endpoints
 type (
@@ -14,9 +17,13 @@ type (
 		Email    string `json:"user_id"`
 		Password string `json:"password"`
 	}
-	// authUserModel for authorized user with access token
+	// authUserModel for authorized user with access and refresh tokens
 	authUserModel struct {
-		User  domain.User `json:"user"`
-		Token string      `json:"token"`
+		User domain.User `json:"user"`
+		auth.TokenPair
+	}
+	// tokenRequest for Post - /users/token/refresh and /users/token/revoke
+	tokenRequest struct {
+		RefreshToken string `json:"refresh_token"`
 	}
 )
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
 
 	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
 )
@@ -23,25 +26,69 @@ func (handler ProductController) PostProduct(w http.ResponseWriter, r *http.Requ
 	// Persistence
 	newProduct, err := handler.Store.Create(product)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("rrror on inserting Product: %w", err)
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error on inserting Product: %w", err)
 	}
 	return newProduct, http.StatusCreated, nil
 }
 
 // HTTP Get - /products
 func (handler ProductController) GetAllProducts(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
-	// ToDo: Write the implementation
-	return nil, http.StatusOK, nil
-
+	products, err := handler.Store.List()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error on querying Products: %w", err)
+	}
+	return products, http.StatusOK, nil
 }
 
 // HTTP Get - /products/{id}
 func (handler ProductController) GetProductById(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
-	// Get id from the incoming url
-	//vars := mux.Vars(r)
-	//id := vars["id"]
+	id, err := productID(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	product, err := handler.Store.GetByID(id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error on querying Product: %w", err)
+	}
+	return product, http.StatusOK, nil
+}
 
-	// ToDo: Write the implementation
-	return nil, http.StatusOK, nil
+// HTTP Put - /products/{id}
+func (handler ProductController) PutProduct(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	id, err := productID(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	var product domain.Product
+	err = json.NewDecoder(r.Body).Decode(&product)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("Unable to decode JSON request body: %w", err)
+	}
+	product.ID = id
+	updated, err := handler.Store.Update(product)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error on updating Product: %w", err)
+	}
+	return updated, http.StatusOK, nil
+}
+
+// HTTP Delete - /products/{id}
+func (handler ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	id, err := productID(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := handler.Store.Delete(id); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error on deleting Product: %w", err)
+	}
+	return nil, http.StatusNoContent, nil
+}
 
+// productID reads the product id from the route variables
+func productID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, fmt.Errorf("Invalid Product id: %w", err)
+	}
+	return id, nil
 }
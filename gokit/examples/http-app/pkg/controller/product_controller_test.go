@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/memstore"
+)
+
+// setUpProductRouter registers the product handlers backed by an in-memory store
+func setUpProductRouter(t *testing.T) (*mux.Router, *memstore.ProductStore) {
+	t.Helper()
+	store := memstore.NewProductStore()
+	handler := ProductController{Store: store}
+	r := mux.NewRouter()
+	r.Handle("/products", ResponseHandler(handler.PostProduct)).Methods("POST")
+	r.Handle("/products", ResponseHandler(handler.GetAllProducts)).Methods("GET")
+	r.Handle("/products/{id}", ResponseHandler(handler.GetProductById)).Methods("GET")
+	r.Handle("/products/{id}", ResponseHandler(handler.PutProduct)).Methods("PUT")
+	r.Handle("/products/{id}", ResponseHandler(handler.DeleteProduct)).Methods("DELETE")
+	return r, store
+}
+
+func serve(r http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestProductController_PostProduct(t *testing.T) {
+	r, _ := setUpProductRouter(t)
+	w := serve(r, "POST", "/products", `{"sku": "SKU-1", "name": "Gopher"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusCreated, w.Code)
+	}
+	var resp struct{ Data domain.Product }
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.ID == 0 || resp.Data.SKU != "SKU-1" {
+		t.Errorf("unexpected product: %+v", resp.Data)
+	}
+}
+
+func TestProductController_PostProduct_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"malformed JSON", `{"sku":`, http.StatusBadRequest},
+		{"missing name", `{"sku": "SKU-2"}`, http.StatusBadRequest},
+		{"duplicate SKU", `{"sku": "SKU-1", "name": "Other"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, store := setUpProductRouter(t)
+			store.Create(domain.Product{SKU: "SKU-1", Name: "Gopher"})
+			w := serve(r, "POST", "/products", tt.body)
+			if w.Code != tt.status {
+				t.Errorf("HTTP Status expected: %d, got: %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestProductController_GetAllProducts(t *testing.T) {
+	r, store := setUpProductRouter(t)
+	store.Create(domain.Product{SKU: "SKU-1", Name: "Gopher"})
+	store.Create(domain.Product{SKU: "SKU-2", Name: "Mascot"})
+	w := serve(r, "GET", "/products", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	var resp struct{ Data []domain.Product }
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data) != 2 {
+		t.Errorf("expected 2 products, got %d", len(resp.Data))
+	}
+}
+
+func TestProductController_GetProductById(t *testing.T) {
+	r, store := setUpProductRouter(t)
+	store.Create(domain.Product{SKU: "SKU-1", Name: "Gopher"})
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"/products/1", http.StatusOK},
+		{"/products/99", http.StatusNotFound},
+		{"/products/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := serve(r, "GET", tt.url, "")
+		if w.Code != tt.status {
+			t.Errorf("GET %s: HTTP Status expected: %d, got: %d", tt.url, tt.status, w.Code)
+		}
+	}
+}
+
+func TestProductController_PutProduct(t *testing.T) {
+	r, store := setUpProductRouter(t)
+	store.Create(domain.Product{SKU: "SKU-1", Name: "Gopher"})
+	store.Create(domain.Product{SKU: "SKU-2", Name: "Mascot"})
+
+	w := serve(r, "PUT", "/products/1", `{"sku": "SKU-1", "name": "Go Gopher"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	p, _ := store.GetByID(1)
+	if p.Name != "Go Gopher" {
+		t.Errorf("expected updated name, got %q", p.Name)
+	}
+
+	w = serve(r, "PUT", "/products/1", `{"sku": "SKU-2", "name": "Go Gopher"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusConflict, w.Code)
+	}
+	w = serve(r, "PUT", "/products/99", `{"sku": "SKU-9", "name": "Missing"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestProductController_DeleteProduct(t *testing.T) {
+	r, store := setUpProductRouter(t)
+	store.Create(domain.Product{SKU: "SKU-1", Name: "Gopher"})
+
+	w := serve(r, "DELETE", "/products/1", "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusNoContent, w.Code)
+	}
+	w = serve(r, "DELETE", "/products/1", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusNotFound, w.Code)
+	}
+}
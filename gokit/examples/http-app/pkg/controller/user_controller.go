@@ -2,7 +2,9 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
@@ -21,6 +23,8 @@ func (handler UserController) PostUser(w http.ResponseWriter, r *http.Request) (
 	if err != nil {
 		return nil, http.StatusBadRequest, fmt.Errorf("unable to decode JSON request body: %w", err)
 	}
+	// Self-registered users are always members; admins are promoted in the database
+	user.User.Role = domain.RoleMember
 	// Persistence
 	newUser, err := handler.Store.Create(user.User, user.Password)
 	if err != nil {
@@ -33,7 +37,6 @@ func (handler UserController) PostUser(w http.ResponseWriter, r *http.Request) (
 // Handler for HTTP Post - "/users/login"
 func (handler UserController) PostLogin(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
 	var loginUser loginUser
-	var token string
 	// Decode the incoming Login json
 	err := json.NewDecoder(r.Body).Decode(&loginUser)
 	if err != nil {
@@ -46,13 +49,70 @@ func (handler UserController) PostLogin(w http.ResponseWriter, r *http.Request)
 		return nil, http.StatusUnauthorized, fmt.Errorf("Authentication failed: %w", err)
 
 	}
-	// Generate JWT token if login is successful
-	token, err = auth.GenerateJWT(user.Email, "member")
+	// Generate JWT tokens if login is successful
+	tokens, err := auth.GenerateTokenPair(user.Email, user.Role)
 	if err != nil {
 		return nil, http.StatusInternalServerError, fmt.Errorf("Error on generating the token: %w", err)
 
 	}
-	authUser := authUserModel{User: user, Token: token}
+	authUser := authUserModel{User: user, TokenPair: tokens}
 	return authUser, http.StatusOK, nil
 
 }
+
+// PostRefreshToken exchanges a refresh token for a new access and refresh token
+// Handler for HTTP Post - "/users/token/refresh"
+func (handler UserController) PostRefreshToken(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("unable to decode JSON request body: %w", err)
+	}
+	tokens, err := auth.RefreshTokens(req.RefreshToken, handler.Store)
+	if err != nil {
+		if auth.IsAuthError(err) {
+			return nil, http.StatusUnauthorized, fmt.Errorf("Invalid refresh token: %w", err)
+		}
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error on refreshing the token: %w", err)
+	}
+	return tokens, http.StatusOK, nil
+}
+
+// PostRevokeToken revokes the refresh token in the body and the access
+// token sent as Bearer token, e.g. on logout; either may be omitted
+// Handler for HTTP Post - "/users/token/revoke"
+func (handler UserController) PostRevokeToken(w http.ResponseWriter, r *http.Request) (interface{}, int, error) {
+	var req tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return nil, http.StatusBadRequest, fmt.Errorf("unable to decode JSON request body: %w", err)
+	}
+	var tokens []*auth.AppClaims
+	if req.RefreshToken != "" {
+		claims, err := auth.ParseToken(req.RefreshToken, auth.RefreshToken)
+		if err != nil {
+			if auth.IsAuthError(err) {
+				return nil, http.StatusUnauthorized, fmt.Errorf("Invalid refresh token: %w", err)
+			}
+			return nil, http.StatusInternalServerError, fmt.Errorf("Error on validating the token: %w", err)
+		}
+		tokens = append(tokens, claims)
+	}
+	claims, err := auth.ParseRequestToken(r)
+	switch {
+	case err == nil:
+		tokens = append(tokens, claims)
+	case errors.Is(err, auth.ErrNoToken):
+	case auth.IsAuthError(err):
+		return nil, http.StatusUnauthorized, fmt.Errorf("Invalid access token: %w", err)
+	default:
+		return nil, http.StatusInternalServerError, fmt.Errorf("Error on validating the token: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, http.StatusBadRequest, errors.New("a refresh token or an access token is required")
+	}
+	for _, claims := range tokens {
+		if err := auth.RevokeClaims(claims); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("Error on revoking the token: %w", err)
+		}
+	}
+	return nil, http.StatusNoContent, nil
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
+)
+
+// setUpTokenRouter registers the token handlers with a fresh signing key
+// and an empty revocation list
+func setUpTokenRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := auth.NewKeySet()
+	ks.AddSigningKey("k1", key)
+	if err := ks.SetActive("k1"); err != nil {
+		t.Fatal(err)
+	}
+	auth.SetKeySet(ks)
+	auth.SetRevocationList(auth.NewMemoryRevocationList())
+
+	handler := UserController{}
+	r := mux.NewRouter()
+	r.Handle("/users/token/revoke", ResponseHandler(handler.PostRevokeToken)).Methods("POST")
+	return r
+}
+
+func revoke(r http.Handler, accessToken, body string) int {
+	req := httptest.NewRequest("POST", "/users/token/revoke", strings.NewReader(body))
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestUserController_PostRevokeToken(t *testing.T) {
+	r := setUpTokenRouter(t)
+	pair, err := auth.GenerateTokenPair("gopher@example.com", "member")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"refresh_token": "` + pair.RefreshToken + `"}`
+	if got := revoke(r, pair.AccessToken, body); got != http.StatusNoContent {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusNoContent, got)
+	}
+	if _, err := auth.ParseToken(pair.AccessToken, auth.AccessToken); err != auth.ErrTokenRevoked {
+		t.Errorf("expected the access token to be revoked, got %v", err)
+	}
+	if _, err := auth.ParseToken(pair.RefreshToken, auth.RefreshToken); err != auth.ErrTokenRevoked {
+		t.Errorf("expected the refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestUserController_PostRevokeToken_AccessTokenOnly(t *testing.T) {
+	r := setUpTokenRouter(t)
+	access, _ := auth.GenerateJWT("gopher@example.com", "member")
+	if got := revoke(r, access, ""); got != http.StatusNoContent {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusNoContent, got)
+	}
+	if _, err := auth.ParseToken(access, auth.AccessToken); err != auth.ErrTokenRevoked {
+		t.Errorf("expected the access token to be revoked, got %v", err)
+	}
+}
+
+func TestUserController_PostRevokeToken_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		body   string
+		status int
+	}{
+		{"no token", "", `{}`, http.StatusBadRequest},
+		{"malformed JSON", "", `{"refresh_token":`, http.StatusBadRequest},
+		{"invalid refresh token", "", `{"refresh_token": "not-a-jwt"}`, http.StatusUnauthorized},
+		{"invalid access token", "not-a-jwt", `{}`, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setUpTokenRouter(t)
+			if got := revoke(r, tt.token, tt.body); got != tt.status {
+				t.Errorf("HTTP Status expected: %d, got: %d", tt.status, got)
+			}
+		})
+	}
+}
@@ -4,9 +4,13 @@ type (
 	UserStore interface {
 		Create(User, string) (User, error)
 		Login(string, string) (User, error)
+		GetByEmail(string) (User, error)
 	}
 	ProductStore interface {
 		Create(Product) (Product, error)
-		// ToDo: Define all contract methods here
+		GetByID(int) (Product, error)
+		List() ([]Product, error)
+		Update(Product) (Product, error)
+		Delete(int) error
 	}
 )
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Errors returned by the store implementations. Callers should test for
+// them with errors.Is, as stores wrap them with more context.
+var (
+	// ErrProductNotFound is returned when no product exists for an ID
+	ErrProductNotFound = errors.New("product not found")
+	// ErrDuplicateSKU is returned when a product with the same SKU exists
+	ErrDuplicateSKU = errors.New("product SKU already exists")
+	// ErrInvalidProduct is returned when Product.Valid fails
+	ErrInvalidProduct = errors.New("invalid product")
+	// ErrUserNotFound is returned when no user exists for an email
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// ErrValidation is matched by every *ValidationError using errors.Is
+var ErrValidation = errors.New("validation failed")
+
+// FieldError describes why a single field is invalid
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists the invalid fields of an entity
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add records an invalid field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is makes errors.Is(err, ErrValidation) true for any ValidationError
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -1,5 +1,11 @@
 package domain
 
+// Roles assigned to users
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
 type (
 	User struct {
 		ID           int    `json:"id,omitempty"`
@@ -7,6 +13,7 @@ type (
 		FirstName    string `json:"firstname"`
 		LastName     string `json:"lastname"`
 		HashPassword []byte `json:"hashpassword,omitempty"`
+		Role         string `json:"role,omitempty"`
 	}
 
 	Product struct {
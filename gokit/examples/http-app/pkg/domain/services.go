@@ -1,23 +1,26 @@
 package domain
 
-import (
-	"github.com/pkg/errors"
-)
-
 // ToDo: All domain services
+
+// Valid validates the Product, returning a *ValidationError listing every
+// invalid field
 func (p Product) Valid() (bool, error) {
-	var err error
+	var verr ValidationError
 	if p.Name == "" {
-		err = errors.New("Name could not be empty")
+		verr.Add("name", "Name could not be empty")
 	}
 	if p.SKU == "" {
-		err = errors.New("SKU could not be empty")
+		verr.Add("sku", "SKU could not be empty")
+	}
+	if p.DiscountPerc < 0 || p.DiscountPerc > 100 {
+		verr.Add("discountPerc", "Discount percentage must be between 0 and 100")
+	}
+	if p.DiscountAmount < 0 {
+		verr.Add("discountAmount", "Discount amount could not be negative")
 	}
 	// Do all validation logic here
-	if err != nil {
-		return false, err
-	} else {
-		return true, err
+	if len(verr.Fields) > 0 {
+		return false, &verr
 	}
-
+	return true, nil
 }
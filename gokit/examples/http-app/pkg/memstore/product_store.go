@@ -0,0 +1,100 @@
+// memstore package provides in-memory implementations of the domain stores,
+// which are used for testing without a database
+package memstore
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+)
+
+// ProductStore is an in-memory domain.ProductStore safe for concurrent use
+type ProductStore struct {
+	mu       sync.RWMutex
+	lastID   int
+	products map[int]domain.Product
+}
+
+// NewProductStore returns an empty ProductStore
+func NewProductStore() *ProductStore {
+	return &ProductStore{products: make(map[int]domain.Product)}
+}
+
+// Create creates a new Product
+func (store *ProductStore) Create(product domain.Product) (domain.Product, error) {
+	if ok, err := product.Valid(); !ok {
+		return product, fmt.Errorf("%w: %w", domain.ErrInvalidProduct, err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.skuExists(product.SKU, 0) {
+		return product, domain.ErrDuplicateSKU
+	}
+	store.lastID++
+	product.ID = store.lastID
+	store.products[product.ID] = product
+	return product, nil
+}
+
+// GetByID returns the Product with the given id
+func (store *ProductStore) GetByID(id int) (domain.Product, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	product, ok := store.products[id]
+	if !ok {
+		return product, domain.ErrProductNotFound
+	}
+	return product, nil
+}
+
+// List returns all Products ordered by id
+func (store *ProductStore) List() ([]domain.Product, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	products := make([]domain.Product, 0, len(store.products))
+	for _, product := range store.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products, nil
+}
+
+// Update updates the Product identified by product.ID
+func (store *ProductStore) Update(product domain.Product) (domain.Product, error) {
+	if ok, err := product.Valid(); !ok {
+		return product, fmt.Errorf("%w: %w", domain.ErrInvalidProduct, err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.products[product.ID]; !ok {
+		return product, domain.ErrProductNotFound
+	}
+	if store.skuExists(product.SKU, product.ID) {
+		return product, domain.ErrDuplicateSKU
+	}
+	store.products[product.ID] = product
+	return product, nil
+}
+
+// Delete deletes the Product with the given id
+func (store *ProductStore) Delete(id int) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.products[id]; !ok {
+		return domain.ErrProductNotFound
+	}
+	delete(store.products, id)
+	return nil
+}
+
+// skuExists reports whether a product other than exceptID uses sku
+func (store *ProductStore) skuExists(sku string, exceptID int) bool {
+	for id, product := range store.products {
+		if id != exceptID && product.SKU == sku {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/shijuvar/gokit/log"
+)
+
+// AccessLog Middleware writes one structured record per request with the
+// method, route template, status, response size and latency
+func AccessLog(logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			r, info := withRouteInfo(r)
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+			logger.Info("request",
+				"request_id", RequestIDFromContext(r.Context()),
+				"method", r.Method,
+				"route", info.route(),
+				"path", r.URL.Path,
+				"status", rec.status,
+				"bytes", rec.bytes,
+				"latency", time.Since(start),
+				"remote", r.RemoteAddr,
+			)
+		})
+	}
+}
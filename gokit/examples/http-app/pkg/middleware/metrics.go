@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics collects request counters and latency histograms per mux route
+// and serves them in the Prometheus text exposition format
+type Metrics struct {
+	buckets  []float64
+	inFlight atomic.Int64
+
+	mu         sync.Mutex
+	requests   map[requestLabels]uint64
+	histograms map[routeLabels]*histogram
+}
+
+type routeLabels struct {
+	method, route string
+}
+
+type requestLabels struct {
+	routeLabels
+	code int
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewMetrics returns an empty Metrics using DefaultBuckets
+func NewMetrics() *Metrics {
+	return &Metrics{
+		buckets:    DefaultBuckets,
+		requests:   make(map[requestLabels]uint64),
+		histograms: make(map[routeLabels]*histogram),
+	}
+}
+
+// Middleware records every request. Routers must use RecordRoute so
+// requests are labelled by route template rather than raw path.
+func (m *Metrics) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.inFlight.Add(1)
+			defer m.inFlight.Add(-1)
+			start := time.Now()
+			r, info := withRouteInfo(r)
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+			m.observe(r.Method, info.route(), rec.status, time.Since(start))
+		})
+	}
+}
+
+func (m *Metrics) observe(method, route string, code int, latency time.Duration) {
+	labels := routeLabels{method: method, route: route}
+	seconds := latency.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestLabels{routeLabels: labels, code: code}]++
+	h, ok := m.histograms[labels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.histograms[labels] = h
+	}
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// Handler serves the collected metrics, e.g. on "/metrics"
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.writeTo(w)
+	})
+}
+
+func (m *Metrics) writeTo(w http.ResponseWriter) {
+	var b strings.Builder
+
+	b.WriteString("# HELP http_requests_in_flight Number of HTTP requests being served.\n")
+	b.WriteString("# TYPE http_requests_in_flight gauge\n")
+	fmt.Fprintf(&b, "http_requests_in_flight %d\n", m.inFlight.Load())
+
+	m.mu.Lock()
+	requests := make([]requestLabels, 0, len(m.requests))
+	for labels := range m.requests {
+		requests = append(requests, labels)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].routeLabels != requests[j].routeLabels {
+			return requests[i].routeLabels.less(requests[j].routeLabels)
+		}
+		return requests[i].code < requests[j].code
+	})
+	b.WriteString("# HELP http_requests_total Total number of HTTP requests by method, route and status code.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, labels := range requests {
+		fmt.Fprintf(&b, "http_requests_total{method=%q,route=%q,code=\"%d\"} %d\n",
+			labels.method, labels.route, labels.code, m.requests[labels])
+	}
+
+	routes := make([]routeLabels, 0, len(m.histograms))
+	for labels := range m.histograms {
+		routes = append(routes, labels)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].less(routes[j]) })
+	b.WriteString("# HELP http_request_duration_seconds Latency of HTTP requests by method and route.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, labels := range routes {
+		h := m.histograms[labels]
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{method=%q,route=%q,le=%q} %d\n",
+				labels.method, labels.route, strconv.FormatFloat(upper, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "http_request_duration_seconds_bucket{method=%q,route=%q,le=\"+Inf\"} %d\n",
+			labels.method, labels.route, h.count)
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum{method=%q,route=%q} %g\n",
+			labels.method, labels.route, h.sum)
+		fmt.Fprintf(&b, "http_request_duration_seconds_count{method=%q,route=%q} %d\n",
+			labels.method, labels.route, h.count)
+	}
+	m.mu.Unlock()
+
+	w.Write([]byte(b.String()))
+}
+
+func (l routeLabels) less(o routeLabels) bool {
+	if l.route != o.route {
+		return l.route < o.route
+	}
+	return l.method < o.method
+}
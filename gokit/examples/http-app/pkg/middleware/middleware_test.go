@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/shijuvar/gokit/log"
+)
+
+// setUpRouter mirrors router.InitRoutes: a root router with a separate
+// router mounted under a path prefix
+func setUpRouter() *mux.Router {
+	productRouter := mux.NewRouter()
+	productRouter.Use(RecordRoute)
+	productRouter.HandleFunc("/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("gopher"))
+	}).Methods("GET")
+
+	router := mux.NewRouter()
+	router.Use(RecordRoute)
+	router.PathPrefix("/products").Handler(productRouter)
+	return router
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if seen != "abc-123" || w.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Errorf("expected propagated request ID, got %q / %q", seen, w.Header().Get(RequestIDHeader))
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if seen == "" || seen == "bad id\n" || w.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("expected a generated request ID, got %q", seen)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(log.NewJSONSink(&buf), log.INFO)
+	h := Apply(setUpRouter(), AccessLog(logger), RequestID())
+
+	req := httptest.NewRequest("GET", "/products/42", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := buf.String()
+	for _, want := range []string{`"request_id":"req-1"`, `"method":"GET"`, `"route":"/products/{id}"`, `"status":200`, `"bytes":6`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("access log %s does not contain %s", out, want)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	h := Apply(setUpRouter(), metrics.Middleware())
+	for _, path := range []string{"/products/1", "/products/2", "/unknown"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	out := w.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/products/{id}",code="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",code="404"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/products/{id}",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/products/{id}"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output does not contain %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(log.NewJSONSink(&buf), log.INFO)
+	h := PanicRecovery(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/products", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusInternalServerError, w.Code)
+	}
+	if out := buf.String(); !strings.Contains(out, `"error":"boom"`) || !strings.Contains(out, `"stack":`) {
+		t.Errorf("expected panic and stack in log, got %s", out)
+	}
+}
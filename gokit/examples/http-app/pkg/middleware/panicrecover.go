@@ -1,9 +1,12 @@
 package middleware
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+	"runtime/debug"
+
+	"github.com/shijuvar/gokit/log"
+
+	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
 )
 
 func PanicRecovery(logger *log.Logger) Middleware {
@@ -13,14 +16,18 @@ func PanicRecovery(logger *log.Logger) Middleware {
 			defer func() {
 				err := recover()
 				if err != nil {
-					logger.Print("Error", err)
-					jsonError, _ := json.Marshal(map[string]string{
-						"error": "There was an internal server error",
-					})
-
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write(jsonError)
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+					logger.Error("panic recovered",
+						"error", err,
+						"request_id", RequestIDFromContext(r.Context()),
+						"method", r.Method,
+						"path", r.URL.Path,
+						"stack", string(debug.Stack()),
+					)
+					util.WriteError(w, r, util.NewAppError(http.StatusInternalServerError,
+						"internal", "There was an internal server error", nil))
 				}
 
 			}()
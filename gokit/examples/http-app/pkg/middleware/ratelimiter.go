@@ -1,23 +1,217 @@
 package middleware
 
 import (
+	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
-	"golang.org/x/time/rate"
+	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
 )
 
-func RateLimiter(limit int) Middleware {
+// Limit allows Requests requests per Period for each client, with bursts
+// of up to Requests requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// rate returns the refill rate in requests per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// RateLimitResult is the outcome of a single RateLimitStore.Allow call
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // time until the next request is allowed, if not Allowed
+	Reset      time.Duration // time until the full quota is available again
+}
+
+// RateLimitStore keeps the limiter state per key. The in-memory store
+// serves a single instance; a shared store lets several instances
+// enforce one limit.
+type RateLimitStore interface {
+	Allow(key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+// KeyFunc identifies the client a request is counted against
+type KeyFunc func(*http.Request) string
+
+// ByIP keys requests by the client IP address of the connection
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// BySubject keys requests by the authenticated user. It must run after
+// auth.AuthorizeRequest and falls back to ByIP for anonymous requests.
+func BySubject(r *http.Request) string {
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return "sub:" + p.UserName
+	}
+	return ByIP(r)
+}
+
+// ByAPIKey keys requests by the value of header, falling back to ByIP
+func ByAPIKey(header string) KeyFunc {
+	return func(r *http.Request) string {
+		if key := r.Header.Get(header); key != "" {
+			return "key:" + key
+		}
+		return ByIP(r)
+	}
+}
+
+// RateLimitConfig configures the RateLimit middleware
+type RateLimitConfig struct {
+	Store RateLimitStore
+	Limit Limit
+	Key   KeyFunc
+	// Scope separates the counters of routes which share a Store
+	Scope string
+}
+
+// RateLimit Middleware limits requests per client and reports the quota
+// in the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+// Rejected requests get 429 with a Retry-After header.
+// It panics if cfg has no Store or a Limit without requests or period.
+func RateLimit(cfg RateLimitConfig) Middleware {
+	switch {
+	case cfg.Store == nil:
+		panic("middleware: RateLimit requires a Store")
+	case cfg.Limit.Requests <= 0:
+		panic(fmt.Sprintf("middleware: RateLimit requires Limit.Requests > 0, got %d", cfg.Limit.Requests))
+	case cfg.Limit.Period <= 0:
+		panic(fmt.Sprintf("middleware: RateLimit requires Limit.Period > 0, got %v", cfg.Limit.Period))
+	}
+	if cfg.Key == nil {
+		cfg.Key = ByIP
+	}
 	return func(next http.Handler) http.Handler {
-		// Limit requests
-		limiter := rate.NewLimiter(1, limit)
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !limiter.Allow() {
-				w.WriteHeader(http.StatusTooManyRequests)
+			key := cfg.Scope + "|" + cfg.Key(r)
+			result, err := cfg.Store.Allow(key, cfg.Limit, time.Now())
+			if err != nil {
+				// Fail open: an unavailable store must not take the API down
+				util.Logger.Error("rate limit store failed", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(cfg.Limit.Requests))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				util.WriteError(w, r, util.NewAppError(http.StatusTooManyRequests,
+					"rate_limited", "Too many requests, retry later", nil))
 				return
 			}
-
 			next.ServeHTTP(w, r)
 		})
 	}
+}
+
+// RateLimiter Middleware allows each client IP a burst of limit requests,
+// refilled at one request per second
+func RateLimiter(limit int) Middleware {
+	return RateLimit(RateLimitConfig{
+		Store: NewMemoryRateLimitStore(10 * time.Minute),
+		Limit: Limit{Requests: limit, Period: time.Duration(limit) * time.Second},
+		Key:   ByIP,
+		Scope: "global",
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// bucket is a token bucket for a single key
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// MemoryRateLimitStore is an in-memory RateLimitStore using token buckets.
+// Buckets idle for longer than the idle timeout are evicted.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	idle      time.Duration
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore returns a store which evicts buckets unused for idle
+func NewMemoryRateLimitStore(idle time.Duration) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+		idle:    idle,
+	}
+}
+
+// Allow takes a token from the bucket of key if one is available
+func (s *MemoryRateLimitStore) Allow(key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	// Refill for the time elapsed since the last request
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+		b.last = now
+	}
+	b.lastSeen = now
+
+	var result RateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((capacity - b.tokens) / rate)
+	return result, nil
+}
+
+// Len returns the number of tracked keys
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// sweep evicts idle buckets at most once per idle period
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if s.idle <= 0 || now.Sub(s.lastSweep) < s.idle {
+		return
+	}
+	for key, b := range s.buckets {
+		if now.Sub(b.lastSeen) >= s.idle {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
 
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
 }
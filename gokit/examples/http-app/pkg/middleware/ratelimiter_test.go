@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore_Allow(t *testing.T) {
+	store := NewMemoryRateLimitStore(time.Minute)
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	now := time.Now()
+
+	for i, want := range []bool{true, true, false} {
+		res, _ := store.Allow("a", limit, now)
+		if res.Allowed != want {
+			t.Fatalf("request %d: allowed = %v, want %v", i, res.Allowed, want)
+		}
+	}
+	res, _ := store.Allow("a", limit, now)
+	if res.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", res.RetryAfter)
+	}
+	// Other keys have their own bucket
+	if res, _ := store.Allow("b", limit, now); !res.Allowed {
+		t.Error("expected a fresh bucket for another key")
+	}
+	// One token is refilled per second
+	if res, _ := store.Allow("a", limit, now.Add(time.Second)); !res.Allowed {
+		t.Error("expected a refilled token after one second")
+	}
+}
+
+func TestMemoryRateLimitStore_EvictsIdle(t *testing.T) {
+	store := NewMemoryRateLimitStore(time.Minute)
+	limit := Limit{Requests: 1, Period: time.Second}
+	now := time.Now()
+	store.Allow("a", limit, now)
+	store.Allow("b", limit, now.Add(30*time.Second))
+	store.Allow("b", limit, now.Add(61*time.Second))
+	if n := store.Len(); n != 1 {
+		t.Errorf("expected idle key to be evicted, %d keys left", n)
+	}
+}
+
+func TestRateLimit_PerClientHeaders(t *testing.T) {
+	h := RateLimit(RateLimitConfig{
+		Store: NewMemoryRateLimitStore(time.Minute),
+		Limit: Limit{Requests: 1, Period: time.Minute},
+		Key:   ByAPIKey("X-API-Key"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/products", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("alice")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected first response: %d %v", w.Code, w.Header())
+	}
+	w = send("alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("HTTP Status expected: %d, got: %d", http.StatusTooManyRequests, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want %q", got, "60")
+	}
+	// A noisy client does not throttle others
+	if w := send("bob"); w.Code != http.StatusOK {
+		t.Errorf("HTTP Status expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimit_InvalidConfig(t *testing.T) {
+	store := NewMemoryRateLimitStore(time.Minute)
+	tests := []struct {
+		name string
+		cfg  RateLimitConfig
+	}{
+		{"no store", RateLimitConfig{Limit: Limit{Requests: 1, Period: time.Minute}}},
+		{"no requests", RateLimitConfig{Store: store, Limit: Limit{Period: time.Minute}}},
+		{"negative requests", RateLimitConfig{Store: store, Limit: Limit{Requests: -1, Period: time.Minute}}},
+		{"no period", RateLimitConfig{Store: store, Limit: Limit{Requests: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected RateLimit to panic")
+				}
+			}()
+			RateLimit(tt.cfg)
+		})
+	}
+	t.Run("RateLimiter(0)", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected RateLimiter to panic")
+			}
+		}()
+		RateLimiter(0)
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// responseRecorder captures the status code and body size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// unmatchedRoute labels requests which did not reach a mux route
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// routeInfo is filled in by RecordRoute once a mux route has matched
+type routeInfo struct {
+	template string
+}
+
+// withRouteInfo makes sure r carries a routeInfo for RecordRoute to fill in
+func withRouteInfo(r *http.Request) (*http.Request, *routeInfo) {
+	if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+		return r, info
+	}
+	info := &routeInfo{}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, info)), info
+}
+
+func (info *routeInfo) route() string {
+	if info.template == "" {
+		return unmatchedRoute
+	}
+	return info.template
+}
+
+// RecordRoute is a mux middleware which records the path template of the
+// matched route, e.g. "/products/{id}", for AccessLog and Metrics. Use it
+// on every router and sub router; the innermost match wins.
+func RecordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(routeKey{}).(*routeInfo); ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					info.template = tpl
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
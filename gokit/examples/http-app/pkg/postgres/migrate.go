@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the versioned schema migrations, named
+// NNNN_description.up.sql and NNNN_description.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key of the advisory lock which serializes
+// migrations across concurrently starting instances
+const migrationLockID = 7261646
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a Migration has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// loadMigrations reads the up/down pairs from fsys, ordered by version
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		base := path.Base(file)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: name must end with .up.sql or .down.sql", base)
+		}
+		stem := strings.TrimSuffix(base, "."+direction+".sql")
+		prefix, name, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_description", base)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", base, err)
+		}
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s: missing up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// MigrateUp applies all pending migrations in version order
+func (store DataStore) MigrateUp(ctx context.Context) error {
+	return store.withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int]time.Time) error {
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := applyMigration(ctx, conn, m, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MigrateDown rolls back the most recently applied migration
+func (store DataStore) MigrateDown(ctx context.Context) error {
+	return store.withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int]time.Time) error {
+		m, ok := lastApplied(migrations, applied)
+		if !ok {
+			return nil
+		}
+		return applyMigration(ctx, conn, m, false)
+	})
+}
+
+// MigrateRedo rolls back and re-applies the most recently applied migration
+func (store DataStore) MigrateRedo(ctx context.Context) error {
+	return store.withMigrationLock(ctx, func(conn *sql.Conn, migrations []Migration, applied map[int]time.Time) error {
+		m, ok := lastApplied(migrations, applied)
+		if !ok {
+			return nil
+		}
+		if err := applyMigration(ctx, conn, m, false); err != nil {
+			return err
+		}
+		return applyMigration(ctx, conn, m, true)
+	})
+}
+
+// MigrationStatus returns every known migration with its applied state.
+// It only reads: it neither waits for the migration lock nor creates
+// schema_migrations, and reports every migration pending without it.
+func (store DataStore) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, fmt.Errorf("Error on loading migrations: %w", err)
+	}
+	var exists bool
+	err = store.Db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL;`).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("Error on querying schema_migrations: %w", err)
+	}
+	applied := map[int]time.Time{}
+	if exists {
+		if applied, err = appliedMigrations(ctx, store.Db); err != nil {
+			return nil, err
+		}
+	}
+	return migrationStatus(migrations, applied), nil
+}
+
+// migrationStatus reports the applied state of each of migrations
+func migrationStatus(migrations []Migration, applied map[int]time.Time) []MigrationStatus {
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.Version]
+		status = append(status, MigrationStatus{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: at,
+		})
+	}
+	return status
+}
+
+// withMigrationLock takes the advisory lock on a dedicated connection,
+// makes sure the tracking table exists and calls fn with the known and
+// applied migrations
+func (store DataStore) withMigrationLock(ctx context.Context,
+	fn func(*sql.Conn, []Migration, map[int]time.Time) error) error {
+
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return fmt.Errorf("Error on loading migrations: %w", err)
+	}
+	// Advisory locks are held per session, so lock and migrate on one connection
+	conn, err := store.Db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("Error on acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("Error on acquiring migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);`)
+	if err != nil {
+		return fmt.Errorf("Error on creating schema_migrations: %w", err)
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, migrations, applied)
+}
+
+// queryer is satisfied by *sql.DB and *sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func appliedMigrations(ctx context.Context, q queryer) (map[int]time.Time, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, fmt.Errorf("Error on querying schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("Error on scanning schema_migrations: %w", err)
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// lastApplied returns the applied migration with the highest version
+func lastApplied(migrations []Migration, applied map[int]time.Time) (Migration, bool) {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; ok {
+			return migrations[i], true
+		}
+	}
+	return Migration{}, false
+}
+
+// applyMigration runs the up or down script of m and records it in
+// schema_migrations within a single transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	script, record := m.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`
+	if !up {
+		if m.Down == "" {
+			return fmt.Errorf("migration %d_%s: missing down script", m.Version, m.Name)
+		}
+		script, record = m.Down, `DELETE FROM schema_migrations WHERE version=$1 AND name=$2;`
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Error on starting migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error on running migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, m.Version, m.Name); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error on recording migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Error on committing migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) < 2 {
+		t.Fatalf("expected at least 2 migrations, got %d", len(migrations))
+	}
+	for i, m := range migrations {
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("migration %d_%s: missing up or down script", m.Version, m.Name)
+		}
+		if i > 0 && migrations[i-1].Version >= m.Version {
+			t.Errorf("migrations out of order: %d before %d", migrations[i-1].Version, m.Version)
+		}
+	}
+	if !strings.Contains(migrations[1].Up, "products") {
+		t.Errorf("expected second migration to create products, got %q", migrations[1].Up)
+	}
+	// users and products were created by hand before migrations existed,
+	// so the first migrate up must not fail on them
+	for _, m := range migrations[:2] {
+		if !strings.Contains(m.Up, "CREATE TABLE IF NOT EXISTS") {
+			t.Errorf("migration %d_%s must tolerate an existing table", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"bad suffix", fstest.MapFS{"migrations/0001_users.sql": {}}},
+		{"bad version", fstest.MapFS{"migrations/abc_users.up.sql": {Data: []byte("SELECT 1;")}}},
+		{"missing up", fstest.MapFS{"migrations/0001_users.down.sql": {Data: []byte("SELECT 1;")}}},
+		{"conflicting names", fstest.MapFS{
+			"migrations/0001_users.up.sql":    {Data: []byte("SELECT 1;")},
+			"migrations/0001_people.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrations(tt.files); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "users"}, {Version: 2, Name: "products"}}
+	at := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Without schema_migrations every migration is pending
+	for _, s := range migrationStatus(migrations, map[int]time.Time{}) {
+		if s.Applied {
+			t.Errorf("migration %d reported applied", s.Version)
+		}
+	}
+	status := migrationStatus(migrations, map[int]time.Time{1: at})
+	if len(status) != 2 || !status[0].Applied || !status[0].AppliedAt.Equal(at) || status[1].Applied {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
@@ -0,0 +1,8 @@
+-- Deployments predating migrations created this table by hand.
+CREATE TABLE IF NOT EXISTS users (
+    id            SERIAL PRIMARY KEY,
+    email_id      VARCHAR(255) NOT NULL UNIQUE,
+    first_name    VARCHAR(100) NOT NULL,
+    last_name     VARCHAR(100) NOT NULL,
+    password_hash BYTEA        NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Deployments predating migrations created this table by hand.
+CREATE TABLE IF NOT EXISTS products (
+    id              SERIAL PRIMARY KEY,
+    sku             VARCHAR(64)      NOT NULL UNIQUE,
+    name            VARCHAR(255)     NOT NULL,
+    discount_perc   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    discount_amount DOUBLE PRECISION NOT NULL DEFAULT 0
+);
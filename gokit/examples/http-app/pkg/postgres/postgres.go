@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
@@ -53,3 +54,13 @@ func New(cfg Config) (DataStore, error) {
 	store.Db = db
 	return store, nil
 }
+
+// Ping checks that the database is reachable, used for readiness probes
+func (store DataStore) Ping(ctx context.Context) error {
+	return store.Db.PingContext(ctx)
+}
+
+// Close closes the database connections, waiting for running queries
+func (store DataStore) Close(ctx context.Context) error {
+	return store.Db.Close()
+}
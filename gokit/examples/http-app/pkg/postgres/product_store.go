@@ -1,15 +1,132 @@
 package postgres
 
-import "github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
 
 // ProductStore provides persistence logic for "products" table
 type ProductStore struct {
 	Store DataStore
 }
 
-// ToDO: Write CRUD operations here
 // Create creates a new Product
 func (productStore ProductStore) Create(product domain.Product) (domain.Product, error) {
-	// ToDo: Write the code here
-	return domain.Product{}, nil
+	if ok, err := product.Valid(); !ok {
+		return product, fmt.Errorf("%w: %w", domain.ErrInvalidProduct, err)
+	}
+	sqlStatement := `
+		INSERT INTO products (sku, name, discount_perc, discount_amount)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id;`
+	err := productStore.Store.Db.QueryRow(sqlStatement,
+		product.SKU, product.Name, product.DiscountPerc, product.DiscountAmount).Scan(&product.ID)
+	if err != nil {
+		return product, fmt.Errorf("Error while inserting on products: %w", mapProductError(err))
+	}
+	return product, nil
+}
+
+// GetByID returns the Product with the given id
+func (productStore ProductStore) GetByID(id int) (domain.Product, error) {
+	var product domain.Product
+	sqlStatement := `
+		SELECT id, sku, name, discount_perc, discount_amount
+		FROM products WHERE id=$1;`
+	err := productStore.Store.Db.QueryRow(sqlStatement, id).Scan(
+		&product.ID, &product.SKU, &product.Name, &product.DiscountPerc, &product.DiscountAmount)
+	if err != nil {
+		return product, fmt.Errorf("Error on querying products: %w", mapProductError(err))
+	}
+	return product, nil
+}
+
+// List returns all Products ordered by id
+func (productStore ProductStore) List() ([]domain.Product, error) {
+	sqlStatement := `
+		SELECT id, sku, name, discount_perc, discount_amount
+		FROM products ORDER BY id;`
+	rows, err := productStore.Store.Db.Query(sqlStatement)
+	if err != nil {
+		return nil, fmt.Errorf("Error on querying products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []domain.Product{}
+	for rows.Next() {
+		var product domain.Product
+		if err := rows.Scan(&product.ID, &product.SKU, &product.Name,
+			&product.DiscountPerc, &product.DiscountAmount); err != nil {
+			return nil, fmt.Errorf("Error on scanning products: %w", err)
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error on querying products: %w", err)
+	}
+	return products, nil
+}
+
+// Update updates the Product identified by product.ID
+func (productStore ProductStore) Update(product domain.Product) (domain.Product, error) {
+	if ok, err := product.Valid(); !ok {
+		return product, fmt.Errorf("%w: %w", domain.ErrInvalidProduct, err)
+	}
+	sqlStatement := `
+		UPDATE products
+		SET sku=$2, name=$3, discount_perc=$4, discount_amount=$5
+		WHERE id=$1;`
+	result, err := productStore.Store.Db.Exec(sqlStatement,
+		product.ID, product.SKU, product.Name, product.DiscountPerc, product.DiscountAmount)
+	if err != nil {
+		return product, fmt.Errorf("Error while updating products: %w", mapProductError(err))
+	}
+	if err := requireRow(result); err != nil {
+		return product, fmt.Errorf("Error while updating products: %w", err)
+	}
+	return product, nil
+}
+
+// Delete deletes the Product with the given id
+func (productStore ProductStore) Delete(id int) error {
+	result, err := productStore.Store.Db.Exec(`DELETE FROM products WHERE id=$1;`, id)
+	if err != nil {
+		return fmt.Errorf("Error while deleting on products: %w", err)
+	}
+	if err := requireRow(result); err != nil {
+		return fmt.Errorf("Error while deleting on products: %w", err)
+	}
+	return nil
+}
+
+// requireRow returns domain.ErrProductNotFound if no row was affected
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrProductNotFound
+	}
+	return nil
+}
+
+// mapProductError translates driver errors into domain errors
+func mapProductError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrProductNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrDuplicateSKU
+	}
+	return err
 }
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+)
+
+// RevokedTokenStore provides persistence logic for "revoked_tokens" table.
+// It satisfies auth.RevocationList, so revocations are shared by all instances.
+type RevokedTokenStore struct {
+	Store DataStore
+}
+
+// Revoke records jti as revoked until expiresAt and purges expired entries.
+// It reports false if jti had been revoked already.
+func (tokenStore RevokedTokenStore) Revoke(jti string, expiresAt time.Time) (bool, error) {
+	sqlStatement := `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING;`
+	result, err := tokenStore.Store.Db.Exec(sqlStatement, jti, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("Error while inserting on revoked_tokens: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("Error while inserting on revoked_tokens: %w", err)
+	}
+	if _, err := tokenStore.Store.Db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < now();`); err != nil {
+		return false, fmt.Errorf("Error while purging revoked_tokens: %w", err)
+	}
+	return inserted == 1, nil
+}
+
+// IsRevoked reports whether jti has been revoked
+func (tokenStore RevokedTokenStore) IsRevoked(jti string) (bool, error) {
+	var exists bool
+	sqlStatement := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti=$1);`
+	if err := tokenStore.Store.Db.QueryRow(sqlStatement, jti).Scan(&exists); err != nil {
+		return false, fmt.Errorf("Error on querying revoked_tokens: %w", err)
+	}
+	return exists, nil
+}
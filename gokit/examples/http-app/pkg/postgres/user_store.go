@@ -2,9 +2,9 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
-	"log"
 
 	"github.com/shijuvar/gokit/examples/http-app/pkg/domain"
 )
@@ -22,13 +22,16 @@ func (userStore UserStore) Create(user domain.User, password string) (domain.Use
 		return user, fmt.Errorf("error on hashing password: %w", err)
 	}
 	user.HashPassword = hpass
+	if user.Role == "" {
+		user.Role = domain.RoleMember
+	}
 	sqlStatement := `
-		INSERT INTO users (email_id, first_name, last_name, password_hash)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (email_id, first_name, last_name, password_hash, role)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id;`
 	id := 0
 	// Use Db.Exec if you don't want returning ID
-	err = userStore.Store.Db.QueryRow(sqlStatement, user.Email, user.FirstName, user.LastName, user.HashPassword).Scan(&id)
+	err = userStore.Store.Db.QueryRow(sqlStatement, user.Email, user.FirstName, user.LastName, user.HashPassword, user.Role).Scan(&id)
 	if err != nil {
 		user.ID = id // assign returning ID
 		return user, fmt.Errorf("Error while inserting on users: %w", err)
@@ -40,11 +43,10 @@ func (userStore UserStore) Create(user domain.User, password string) (domain.Use
 func (userStore UserStore) Login(email, password string) (domain.User, error) {
 	var user domain.User
 	var err error
-	sqlStatement := `SELECT first_name,last_name,password_hash FROM users where email_id=$1;`
-	log.Printf(email)
+	sqlStatement := `SELECT id,email_id,first_name,last_name,password_hash,role FROM users where email_id=$1;`
 	row := userStore.Store.Db.QueryRow(sqlStatement, email)
 
-	switch err = row.Scan(&user.FirstName, &user.LastName, &user.HashPassword); err {
+	switch err = row.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.HashPassword, &user.Role); err {
 	case sql.ErrNoRows:
 		err = fmt.Errorf("Invalid Email Id: %w", err)
 	case nil:
@@ -59,3 +61,18 @@ func (userStore UserStore) Login(email, password string) (domain.User, error) {
 
 	return user, err
 }
+
+// GetByEmail returns the User with the given email, without its password hash
+func (userStore UserStore) GetByEmail(email string) (domain.User, error) {
+	var user domain.User
+	sqlStatement := `SELECT id,email_id,first_name,last_name,role FROM users where email_id=$1;`
+	row := userStore.Store.Db.QueryRow(sqlStatement, email)
+	err := row.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Role)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return user, fmt.Errorf("Invalid Email Id: %w", domain.ErrUserNotFound)
+	case err != nil:
+		return user, fmt.Errorf("Error on querying data: %w", err)
+	}
+	return user, nil
+}
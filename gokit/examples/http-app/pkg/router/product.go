@@ -1,23 +1,41 @@
 package router
 
 import (
+	"time"
+
 	"github.com/gorilla/mux"
 
 	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/controller"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/middleware"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/postgres"
 )
 
-// SetUserRoutes registers routes for user product
+// SetProductRoutes registers routes for product entity
 func SetProductRoutes(router *mux.Router, store postgres.DataStore) *mux.Router {
 
 	productStore := postgres.ProductStore{Store: store}
 	productController := controller.ProductController{Store: productStore}
 	productRouter := mux.NewRouter()
+	productRouter.Use(middleware.RecordRoute)
+
+	// Members can read products; only admins can change them
+	canRead := auth.RequirePermission(auth.ReadProducts)
+	canWrite := auth.RequirePermission(auth.WriteProducts)
+	// Writes are limited per user on top of the global per-IP limit
+	writeLimit := middleware.RateLimit(middleware.RateLimitConfig{
+		Store: rateLimitStore,
+		Limit: middleware.Limit{Requests: 30, Period: time.Minute},
+		Key:   middleware.BySubject,
+		Scope: "products:write",
+	})
+	canWrite = chain(canWrite, writeLimit)
 
-	productRouter.Handle("/products", controller.ResponseHandler(productController.PostProduct)).Methods("POST")
-	productRouter.Handle("/products", controller.ResponseHandler(productController.GetAllProducts)).Methods("GET")
-	productRouter.Handle("/products/{id}", controller.ResponseHandler(productController.GetProductById)).Methods("GET")
+	productRouter.Handle("/products", canWrite(controller.ResponseHandler(productController.PostProduct))).Methods("POST")
+	productRouter.Handle("/products", canRead(controller.ResponseHandler(productController.GetAllProducts))).Methods("GET")
+	productRouter.Handle("/products/{id}", canRead(controller.ResponseHandler(productController.GetProductById))).Methods("GET")
+	productRouter.Handle("/products/{id}", canWrite(controller.ResponseHandler(productController.PutProduct))).Methods("PUT")
+	productRouter.Handle("/products/{id}", canWrite(controller.ResponseHandler(productController.DeleteProduct))).Methods("DELETE")
 	// Applying authorization middleware
 	router.PathPrefix("/products").Handler(auth.AuthorizeRequest(productRouter))
 	return router
@@ -1,29 +1,35 @@
 package router
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/gorilla/mux"
 
-	util "github.com/shijuvar/gokit/examples/http-app/pkg/apputil"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
+	"github.com/shijuvar/gokit/examples/http-app/pkg/middleware"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/postgres"
 )
 
-// InitRoutes registers all routes for the application.
-func InitRoutes() *mux.Router {
+// rateLimitStore holds the per-route rate limiter state of this instance
+var rateLimitStore = middleware.NewMemoryRateLimitStore(10 * time.Minute)
 
-	// Create config for Postgres
-	config := postgres.Config{
-		Host:     util.AppConfig.DBHost,
-		Port:     util.AppConfig.DBPort,
-		User:     util.AppConfig.DBUser,
-		Password: util.AppConfig.DBPassword,
-		Database: util.AppConfig.Database,
-	}
-	// Creates a Postgres DB instance
-	dataStore, err := postgres.New(config)
-	if err != nil {
-		panic(err)
+// chain combines middlewares, the first one running outermost
+func chain(mw ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		for i := len(mw) - 1; i >= 0; i-- {
+			h = mw[i](h)
+		}
+		return h
 	}
+}
+
+// InitRoutes registers all routes for the application.
+func InitRoutes(dataStore postgres.DataStore) *mux.Router {
+	// Share revoked tokens across all instances
+	auth.SetRevocationList(postgres.RevokedTokenStore{Store: dataStore})
 	router := mux.NewRouter()
+	router.Use(middleware.RecordRoute)
 	router = SetUserRoutes(router, dataStore)
 	router = SetProductRoutes(router, dataStore)
 	return router
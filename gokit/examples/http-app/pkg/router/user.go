@@ -3,6 +3,7 @@ package router
 import (
 	"github.com/gorilla/mux"
 
+	"github.com/shijuvar/gokit/examples/http-app/pkg/auth"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/controller"
 	"github.com/shijuvar/gokit/examples/http-app/pkg/postgres"
 )
@@ -14,5 +15,8 @@ func SetUserRoutes(router *mux.Router, store postgres.DataStore) *mux.Router {
 	userController := controller.UserController{Store: userStore}
 	router.Handle("/users", controller.ResponseHandler(userController.PostUser)).Methods("POST")
 	router.Handle("/users/login", controller.ResponseHandler(userController.PostLogin)).Methods("POST")
+	router.Handle("/users/token/refresh", controller.ResponseHandler(userController.PostRefreshToken)).Methods("POST")
+	router.Handle("/users/token/revoke", controller.ResponseHandler(userController.PostRevokeToken)).Methods("POST")
+	router.HandleFunc("/.well-known/jwks.json", auth.JWKSHandler).Methods("GET")
 	return router
 }
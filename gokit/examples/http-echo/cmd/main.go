@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 
 	apphttp "github.com/shijuvar/gokit/examples/http-echo/http"
 	"github.com/shijuvar/gokit/examples/http-echo/memstore"
+	"github.com/shijuvar/gokit/examples/http-echo/model"
 )
 
 func main() {
-	repo, err := memstore.NewInmemoryRepository() // With in-memory database
+	repo, closeRepo, err := newRepository()
 	if err != nil {
 		log.Fatal("Error:", err)
 	}
@@ -29,6 +36,36 @@ func main() {
 	e.POST("/api/notes", h.Post)
 	e.PUT("/api/notes/:id", h.Put)
 	e.DELETE("/api/notes/:id", h.Delete)
-	// Start server
-	e.Logger.Fatal(e.Start(":3000"))
+	// Start server; stop on SIGINT/SIGTERM, so the notes are saved before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := e.Start(":3000"); err != nil && err != http.ErrServerClosed {
+			e.Logger.Error(err)
+			stop()
+		}
+	}()
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		e.Logger.Error(err)
+	}
+	if err := closeRepo(); err != nil {
+		e.Logger.Fatal(err)
+	}
+}
+
+// newRepository returns the in-memory repository, saved in the directory
+// of NOTES_DATA_DIR if set
+func newRepository() (model.Repository, func() error, error) {
+	if dir := os.Getenv("NOTES_DATA_DIR"); dir != "" {
+		repo, err := memstore.NewPersistentRepository(dir, memstore.Options{}) // With notes kept on disk
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, repo.Close, nil
+	}
+	repo, err := memstore.NewInmemoryRepository() // With in-memory database
+	return repo, func() error { return nil }, err
 }
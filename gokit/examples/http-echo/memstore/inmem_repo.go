@@ -3,6 +3,7 @@ package memstore
 import (
 	// internal
 	"errors"
+	"sync"
 	"time"
 
 	// external
@@ -12,26 +13,32 @@ import (
 )
 
 // inmemoryRepository provides concrete implementation
-// for repository interface
+// for repository interface. It is safe for concurrent use.
 type inmemoryRepository struct {
+	mu        sync.RWMutex
 	noteStore map[string]model.Note
+	titles    map[string]string // title to NoteID, keeps titles unique
+	log       *diskLog          // nil unless the notes are persisted
 }
 
 func NewInmemoryRepository() (model.Repository, error) {
+	return newInmemoryRepository(), nil
+}
+
+func newInmemoryRepository() *inmemoryRepository {
 	return &inmemoryRepository{
 		noteStore: make(map[string]model.Note),
-	}, nil
+		titles:    make(map[string]string),
+	}
 }
 
 func (i *inmemoryRepository) isNoteTitleExists(title string) bool {
-	for _, v := range i.noteStore {
-		if v.Title == title {
-			return true
-		}
-	}
-	return false
+	_, ok := i.titles[title]
+	return ok
 }
 func (i *inmemoryRepository) Create(n model.Note) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	if _, ok := i.noteStore[n.NoteID]; ok {
 		return errors.New("NoteID exists")
 	}
@@ -42,27 +49,35 @@ func (i *inmemoryRepository) Create(n model.Note) error {
 	// Create a Version 4 UUID.
 	uid, _ := uuid.NewV4()
 	n.NoteID = uid.String()
-	i.noteStore[n.NoteID] = n
-	return nil
+	return i.commit(record{Op: opPut, Note: &n})
 }
 
 func (i *inmemoryRepository) Update(id string, n model.Note) error {
-	if _, ok := i.noteStore[id]; !ok {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	existing, ok := i.noteStore[id]
+	if !ok {
 		return errors.New("NoteID doesn't exist")
 	}
-	n.CreatedOn = time.Now()
-	i.noteStore[id] = n
-	return nil
+	if n.Title != existing.Title && i.isNoteTitleExists(n.Title) {
+		return model.ErrNoteExists
+	}
+	n.NoteID = id
+	n.CreatedOn = existing.CreatedOn
+	return i.commit(record{Op: opPut, Note: &n})
 }
 
 func (i *inmemoryRepository) Delete(id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	if _, ok := i.noteStore[id]; !ok {
 		return errors.New("NoteID doesn't exist")
 	}
-	delete(i.noteStore, id)
-	return nil
+	return i.commit(record{Op: opDelete, NoteID: id})
 }
 func (i *inmemoryRepository) GetById(id string) (model.Note, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	if v, ok := i.noteStore[id]; !ok {
 		return model.Note{}, errors.New("NoteID doesn't exist")
 	} else {
@@ -72,9 +87,46 @@ func (i *inmemoryRepository) GetById(id string) (model.Note, error) {
 }
 
 func (i *inmemoryRepository) GetAll() ([]model.Note, error) {
-	notes := make([]model.Note, 0)
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	notes := make([]model.Note, 0, len(i.noteStore))
 	for _, v := range i.noteStore {
 		notes = append(notes, v)
 	}
 	return notes, nil
 }
+
+// commit writes r to the log, if any, and applies it. The caller must
+// hold the write lock.
+func (i *inmemoryRepository) commit(r record) error {
+	if i.log != nil {
+		if err := i.log.append(r); err != nil {
+			return err
+		}
+	}
+	i.apply(r)
+	if i.log != nil && i.log.due() {
+		// The change is already in the log, so a failed snapshot only
+		// means the log keeps growing until the next one succeeds
+		i.log.snapshot(i.noteStore)
+	}
+	return nil
+}
+
+// apply changes the notes and the title index; it is also used to
+// replay the log
+func (i *inmemoryRepository) apply(r record) {
+	switch r.Op {
+	case opPut:
+		if old, ok := i.noteStore[r.Note.NoteID]; ok {
+			delete(i.titles, old.Title)
+		}
+		i.noteStore[r.Note.NoteID] = *r.Note
+		i.titles[r.Note.Title] = r.Note.NoteID
+	case opDelete:
+		if old, ok := i.noteStore[r.NoteID]; ok {
+			delete(i.titles, old.Title)
+			delete(i.noteStore, r.NoteID)
+		}
+	}
+}
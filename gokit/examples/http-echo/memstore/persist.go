@@ -0,0 +1,272 @@
+package memstore
+
+import (
+	// internal
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/shijuvar/gokit/examples/http-echo/model"
+)
+
+const (
+	snapshotFile = "notes.snapshot.json"
+	logFile      = "notes.wal"
+
+	// DefaultSnapshotEvery is the default of Options.SnapshotEvery
+	DefaultSnapshotEvery = 1000
+)
+
+// Options configures a persistent repository
+type Options struct {
+	// SnapshotEvery is the number of logged changes after which all notes
+	// are written to a snapshot and the log is truncated
+	SnapshotEvery int
+	// NoSync skips the fsync after each change. Faster, but the last
+	// changes may be lost if the machine crashes.
+	NoSync bool
+}
+
+// PersistentRepository is an in-memory repository whose changes are kept
+// in a write-ahead log and periodic snapshots. It must be closed.
+type PersistentRepository interface {
+	model.Repository
+	// Snapshot writes all notes to the snapshot and truncates the log
+	Snapshot() error
+	// Close writes a final snapshot and closes the log
+	Close() error
+}
+
+// NewPersistentRepository returns a repository which loads the notes
+// saved in dir, creating it if needed, and saves every change there.
+// Only one process may use dir at a time.
+func NewPersistentRepository(dir string, opts Options) (PersistentRepository, error) {
+	i := newInmemoryRepository()
+	l, err := openLog(dir, opts, i.apply)
+	if err != nil {
+		return nil, err
+	}
+	i.log = l
+	return i, nil
+}
+
+func (i *inmemoryRepository) Snapshot() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.log == nil {
+		return nil
+	}
+	return i.log.snapshot(i.noteStore)
+}
+
+func (i *inmemoryRepository) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.log == nil {
+		return nil
+	}
+	err := i.log.snapshot(i.noteStore)
+	if cerr := i.log.file.Close(); err == nil {
+		err = cerr
+	}
+	// Later changes fail to be logged rather than being kept in memory only
+	return err
+}
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+// record is one line of the log. Puts carry the whole note, so replaying
+// a record which is already in the snapshot is harmless.
+type record struct {
+	Op     string      `json:"op"`
+	Note   *model.Note `json:"note,omitempty"`
+	NoteID string      `json:"noteid,omitempty"`
+}
+
+// diskLog appends records to the log file of a directory
+type diskLog struct {
+	dir     string
+	file    *os.File
+	opts    Options
+	size    int64 // of the log file
+	records int   // since the last snapshot
+}
+
+// openLog replays the snapshot and the log in dir and opens the log
+// for appending
+func openLog(dir string, opts Options, replay func(record)) (*diskLog, error) {
+	if opts.SnapshotEvery <= 0 {
+		opts.SnapshotEvery = DefaultSnapshotEvery
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := loadSnapshot(filepath.Join(dir, snapshotFile), replay); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	n, size, err := replayLog(file, replay)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &diskLog{dir: dir, file: file, opts: opts, size: size, records: n}, nil
+}
+
+func loadSnapshot(path string, replay func(record)) error {
+	j, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var notes []model.Note
+	if err := json.Unmarshal(j, &notes); err != nil {
+		return fmt.Errorf("memstore: corrupt snapshot %s: %w", path, err)
+	}
+	for k := range notes {
+		replay(record{Op: opPut, Note: &notes[k]})
+	}
+	return nil
+}
+
+// replayLog applies the records of the log and returns their number and
+// the size of the log. A last record without its newline was cut short by
+// a crash before it was acknowledged, so it is dropped.
+func replayLog(file *os.File, replay func(record)) (int, int64, error) {
+	r := bufio.NewReader(file)
+	var n int
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				return n, offset, file.Truncate(offset)
+			}
+			return n, offset, nil
+		}
+		if err != nil {
+			return n, offset, err
+		}
+		var rec record
+		if err := json.Unmarshal(bytes.TrimSpace(line), &rec); err != nil || !rec.valid() {
+			return n, offset, fmt.Errorf("memstore: corrupt log record at offset %d of %s", offset, file.Name())
+		}
+		replay(rec)
+		offset += int64(len(line))
+		n++
+	}
+}
+
+func (r record) valid() bool {
+	switch r.Op {
+	case opPut:
+		return r.Note != nil && r.Note.NoteID != ""
+	case opDelete:
+		return r.NoteID != ""
+	}
+	return false
+}
+
+// append writes r to the log, synced to disk unless Options.NoSync
+func (l *diskLog) append(r record) error {
+	j, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	j = append(j, '\n')
+	_, err = l.file.Write(j)
+	if err == nil && !l.opts.NoSync {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		// Drop what was written, so the record is neither replayed
+		// nor followed by the next one on the same line
+		l.file.Truncate(l.size)
+		l.file.Seek(l.size, io.SeekStart)
+		return err
+	}
+	l.size += int64(len(j))
+	l.records++
+	return nil
+}
+
+// due reports whether it is time for a snapshot
+func (l *diskLog) due() bool {
+	return l.records >= l.opts.SnapshotEvery
+}
+
+// snapshot replaces the snapshot with notes and truncates the log. The
+// new snapshot is renamed into place, so a crash leaves either the old
+// snapshot and the full log or the new snapshot.
+func (l *diskLog) snapshot(notes map[string]model.Note) error {
+	list := make([]model.Note, 0, len(notes))
+	for _, n := range notes {
+		list = append(list, n)
+	}
+	j, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, snapshotFile)
+	if err := writeFileSync(path+".tmp", j); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+	if err := syncDir(l.dir); err != nil {
+		return err
+	}
+	if err := l.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	l.size = 0
+	l.records = 0
+	return l.file.Sync()
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir makes a rename in dir durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,155 @@
+package memstore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shijuvar/gokit/examples/http-echo/model"
+)
+
+func noteByTitle(t *testing.T, repo model.Repository, title string) model.Note {
+	t.Helper()
+	notes, err := repo.GetAll()
+	require.NoError(t, err)
+	for _, n := range notes {
+		if n.Title == title {
+			return n
+		}
+	}
+	t.Fatalf("note %q not found", title)
+	return model.Note{}
+}
+
+func TestPersistentRepository_Restart(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewPersistentRepository(dir, Options{})
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(model.Note{Title: "mux", Description: "Gorilla mux"}))
+	require.NoError(t, repo.Create(model.Note{Title: "zap", Description: "Uber zap"}))
+	require.NoError(t, repo.Create(model.Note{Title: "echo", Description: "Echo"}))
+	mux := noteByTitle(t, repo, "mux")
+	require.NoError(t, repo.Update(mux.NoteID, model.Note{Title: "gorilla/mux", Description: "A router"}))
+	require.NoError(t, repo.Delete(noteByTitle(t, repo, "zap").NoteID))
+
+	// Reopen without Close, as after a crash: the log is replayed
+	crashed, err := NewPersistentRepository(dir, Options{})
+	require.NoError(t, err)
+	notes, _ := crashed.GetAll()
+	assert.Len(t, notes, 2)
+	got := noteByTitle(t, crashed, "gorilla/mux")
+	assert.Equal(t, "A router", got.Description)
+	assert.True(t, mux.CreatedOn.Equal(got.CreatedOn), "CreatedOn changed from %v to %v", mux.CreatedOn, got.CreatedOn)
+	// The title index is rebuilt too
+	assert.Equal(t, model.ErrNoteExists, crashed.Create(model.Note{Title: "echo"}))
+	assert.NoError(t, crashed.Create(model.Note{Title: "zap"}))
+	require.NoError(t, crashed.Close())
+
+	// Close leaves a snapshot and an empty log
+	info, err := os.Stat(filepath.Join(dir, logFile))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+	reopened, err := NewPersistentRepository(dir, Options{})
+	require.NoError(t, err)
+	defer reopened.Close()
+	notes, _ = reopened.GetAll()
+	assert.Len(t, notes, 3)
+}
+
+func TestPersistentRepository_SnapshotEvery(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewPersistentRepository(dir, Options{SnapshotEvery: 2, NoSync: true})
+	require.NoError(t, err)
+	for k := 0; k < 5; k++ {
+		require.NoError(t, repo.Create(model.Note{Title: fmt.Sprint("note ", k)}))
+	}
+	// Snapshots after the 2nd and 4th change leave one record in the log
+	lines := readLines(t, filepath.Join(dir, logFile))
+	assert.Len(t, lines, 1)
+
+	reopened, err := NewPersistentRepository(dir, Options{})
+	require.NoError(t, err)
+	defer reopened.Close()
+	notes, _ := reopened.GetAll()
+	assert.Len(t, notes, 5)
+}
+
+func TestPersistentRepository_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := NewPersistentRepository(dir, Options{})
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(model.Note{Title: "mux"}))
+
+	// A crash in the middle of writing a record
+	f, err := os.OpenFile(filepath.Join(dir, logFile), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	f.WriteString(`{"op":"put","note":{"noteid":"x","tit`)
+	f.Close()
+
+	reopened, err := NewPersistentRepository(dir, Options{})
+	require.NoError(t, err)
+	require.NoError(t, reopened.Create(model.Note{Title: "zap"}))
+	notes, _ := reopened.GetAll()
+	assert.Len(t, notes, 2)
+	assert.Len(t, readLines(t, filepath.Join(dir, logFile)), 2)
+	reopened.Close()
+
+	// Garbage before the last record is not silently skipped
+	os.WriteFile(filepath.Join(dir, logFile), []byte("garbage\n{}\n"), 0o644)
+	_, err = NewPersistentRepository(dir, Options{})
+	assert.Error(t, err)
+}
+
+func TestInmemoryRepository_Concurrent(t *testing.T) {
+	repo, _ := NewInmemoryRepository()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for k := 0; k < 50; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			// Every title is tried twice, only one may win
+			if repo.Create(model.Note{Title: fmt.Sprint("note ", k%25)}) == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+			repo.GetAll()
+		}(k)
+	}
+	wg.Wait()
+	notes, _ := repo.GetAll()
+	assert.Equal(t, 25, created)
+	assert.Len(t, notes, 25)
+}
+
+func TestInmemoryRepository_UpdateKeepsCreatedOn(t *testing.T) {
+	repo, _ := NewInmemoryRepository()
+	require.NoError(t, repo.Create(model.Note{Title: "mux"}))
+	created := noteByTitle(t, repo, "mux")
+	require.NoError(t, repo.Update(created.NoteID, model.Note{Title: "gorilla/mux"}))
+	updated, err := repo.GetById(created.NoteID)
+	require.NoError(t, err)
+	assert.Equal(t, "gorilla/mux", updated.Title)
+	assert.Equal(t, created.CreatedOn, updated.CreatedOn)
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	j, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var lines []string
+	for _, line := range bytes.Split(j, []byte("\n")) {
+		if len(line) > 0 {
+			lines = append(lines, string(line))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/shijuvar/gokit/examples/http/restapi/model"
+)
+
+// MergePatchContentType is the media type of JSON Merge Patch documents
+const MergePatchContentType = "application/merge-patch+json"
+
+// maxPatchAttempts bounds the retries of a Patch without If-Match
+const maxPatchAttempts = 3
+
+var errInvalidETag = errors.New("Invalid ETag")
+
+// etag returns the strong entity tag of a note version
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch returns the versions listed in an If-Match header, or
+// anyTag if it is "*". Weak tags never match, as If-Match uses the strong
+// comparison.
+func parseIfMatch(header string) (versions []int64, anyTag bool, err error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, false, nil
+	}
+	if header == "*" {
+		return nil, true, nil
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			return nil, false, errInvalidETag
+		}
+		v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || v <= 0 {
+			// Not one of ours, so it cannot match
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return nil, false, errInvalidETag
+	}
+	return versions, false, nil
+}
+
+func isMergePatch(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == MergePatchContentType || mediaType == "application/json")
+}
+
+// applyMergePatch applies patch to the JSON form of note. Only the title,
+// description and tags are taken from the result; the other fields are
+// maintained by the repository.
+func applyMergePatch(note model.Note, patch interface{}) (model.Note, error) {
+	j, err := json.Marshal(note)
+	if err != nil {
+		return note, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(j, &doc); err != nil {
+		return note, err
+	}
+	j, err = json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return note, err
+	}
+	var patched model.Note
+	if err := json.Unmarshal(j, &patched); err != nil {
+		return note, err
+	}
+	note.Title = patched.Title
+	note.Description = patched.Description
+	note.Tags = patched.Tags
+	return note, nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7386
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
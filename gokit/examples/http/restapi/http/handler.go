@@ -2,8 +2,13 @@ package http
 
 import (
 	// internal
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	// external
 	"github.com/gorilla/mux"
@@ -12,9 +17,14 @@ import (
 	"github.com/shijuvar/gokit/examples/http/restapi/model"
 )
 
+// DefaultTimeout bounds the repository calls of a request when
+// NoteHandler.Timeout is not set
+const DefaultTimeout = 5 * time.Second
+
 type NoteHandler struct {
 	Repository model.Repository // interface for persistence
 	Logger     *zap.Logger      // Uber's Zap logger
+	Timeout    time.Duration    // deadline of the repository calls of a request
 }
 
 //HTTP Post - /api/notes
@@ -32,8 +42,10 @@ func (h *NoteHandler) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx, cancel := h.opContext(r)
+	defer cancel()
 	// Create note
-	if err := h.Repository.Create(note); err != nil {
+	if err := h.Repository.Create(ctx, note); err != nil {
 		h.Logger.Error(err.Error(),
 			zap.String("url", r.URL.String()),
 		)
@@ -41,7 +53,7 @@ func (h *NoteHandler) Post(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 	h.Logger.Info("created note",
@@ -51,28 +63,47 @@ func (h *NoteHandler) Post(w http.ResponseWriter, r *http.Request) {
 }
 
 //HTTP Get - /api/notes
+// Query parameters: q (text in the title or description), tag, sort
+// (createdon, updatedon or title, "-" prefixed for descending; default
+// -createdon), limit and cursor. A Link header with rel="next" points to
+// the next page.
 func (h *NoteHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	//Flushing any buffered log entries
 	defer h.Logger.Sync()
-	// Get all
-	if notes, err := h.Repository.GetAll(); err != nil {
+	query, err := parseNoteQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := h.opContext(r)
+	defer cancel()
+	// Find a page
+	if page, err := h.Repository.Find(ctx, query); err != nil {
 		h.Logger.Error(err.Error(),
 			zap.String("url", r.URL.String()),
 		)
-		if err == model.ErrNotFound {
+		if err == model.ErrInvalidQuery || err == model.ErrInvalidCursor {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 
 	} else {
-		j, err := json.Marshal(notes)
+		j, err := json.Marshal(page.Notes)
 		if err != nil {
 			h.Logger.Error(err.Error(),
 				zap.String("url", r.URL.String()),
 			)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if page.Next != "" {
+			next := *r.URL
+			v := next.Query()
+			v.Set("cursor", page.Next)
+			next.RawQuery = v.Encode()
+			w.Header().Set("Link", "<"+next.RequestURI()+`>; rel="next"`)
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -86,8 +117,10 @@ func (h *NoteHandler) Get(w http.ResponseWriter, r *http.Request) {
 	defer h.Logger.Sync()
 	vars := mux.Vars(r)
 	id := vars["id"]
+	ctx, cancel := h.opContext(r)
+	defer cancel()
 	// Get by id
-	if note, err := h.Repository.GetById(id); err != nil {
+	if note, err := h.Repository.GetById(ctx, id); err != nil {
 		h.Logger.Error(err.Error(),
 			zap.String("note id", id),
 			zap.String("url", r.URL.String()),
@@ -96,10 +129,11 @@ func (h *NoteHandler) Get(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	} else {
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag(note.Version))
 		j, err := json.Marshal(note)
 		if err != nil {
 			h.Logger.Error(err.Error(),
@@ -113,6 +147,7 @@ func (h *NoteHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 //HTTP Put - /api/notes/{id}
+// If-Match with the ETag of a previous Get makes the update conditional
 func (h *NoteHandler) Put(w http.ResponseWriter, r *http.Request) {
 	//Flushing any buffered log entries
 	defer h.Logger.Sync()
@@ -128,34 +163,153 @@ func (h *NoteHandler) Put(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	ctx, cancel := h.opContext(r)
+	defer cancel()
+	// The version comes from If-Match, never from the body
+	note.Version, err = h.expectedVersion(ctx, r, id)
+	if err != nil {
+		h.writeUpdateError(w, r, id, err)
+		return
+	}
 	// Update
-	if err := h.Repository.Update(id, note); err != nil {
+	updated, err := h.Repository.Update(ctx, id, note)
+	if err != nil {
+		h.writeUpdateError(w, r, id, err)
+		return
+	}
+	h.Logger.Info("updated note",
+		zap.String("note id", id),
+		zap.String("url", r.URL.String()),
+	)
+	w.Header().Set("ETag", etag(updated.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//HTTP Patch - /api/notes/{id}
+// The body is a JSON Merge Patch (RFC 7386) of the note
+func (h *NoteHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	//Flushing any buffered log entries
+	defer h.Logger.Sync()
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if !isMergePatch(r.Header.Get("Content-Type")) {
+		http.Error(w, "Content-Type must be "+MergePatchContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	var patch interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
 		h.Logger.Error(err.Error(),
-			zap.String("note id", id),
 			zap.String("url", r.URL.String()),
 		)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	h.Logger.Info("updated note",
+	if _, ok := patch.(map[string]interface{}); !ok {
+		http.Error(w, "Merge patch must be a JSON object", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := h.opContext(r)
+	defer cancel()
+	version, err := h.expectedVersion(ctx, r, id)
+	if err != nil {
+		h.writeUpdateError(w, r, id, err)
+		return
+	}
+	// Without If-Match, a note changed between reading and writing it is
+	// read again; with it, the client gets 412
+	var updated model.Note
+	for attempt := 0; ; attempt++ {
+		var note model.Note
+		note, err = h.Repository.GetById(ctx, id)
+		if err != nil {
+			break
+		}
+		if version != 0 && note.Version != version {
+			err = model.ErrVersionConflict
+			break
+		}
+		if note, err = applyMergePatch(note, patch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		updated, err = h.Repository.Update(ctx, id, note)
+		if err != model.ErrVersionConflict || version != 0 || attempt == maxPatchAttempts-1 {
+			break
+		}
+	}
+	if err != nil {
+		h.writeUpdateError(w, r, id, err)
+		return
+	}
+	h.Logger.Info("patched note",
 		zap.String("note id", id),
 		zap.String("url", r.URL.String()),
 	)
+	w.Header().Set("ETag", etag(updated.Version))
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// writeUpdateError maps the errors of Put and Patch to HTTP statuses
+func (h *NoteHandler) writeUpdateError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	h.Logger.Error(err.Error(),
+		zap.String("note id", id),
+		zap.String("url", r.URL.String()),
+	)
+	switch err {
+	case model.ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case model.ErrVersionConflict:
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case model.ErrNoteExists:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), errorStatus(err))
+	}
+}
+
+// expectedVersion returns the version required by the If-Match header,
+// or 0 if there is none. A list of ETags is resolved against the
+// current version of the note.
+func (h *NoteHandler) expectedVersion(ctx context.Context, r *http.Request, id string) (int64, error) {
+	versions, anyTag, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		return 0, model.ErrVersionConflict
+	}
+	switch {
+	case anyTag:
+		// "*" only requires the note to exist, which Update checks
+		return 0, nil
+	case len(versions) == 0:
+		return 0, nil
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+	note, err := h.Repository.GetById(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range versions {
+		if v == note.Version {
+			return v, nil
+		}
+	}
+	return 0, model.ErrVersionConflict
+}
+
 //HTTP Delete - /api/notes/{id}
 func (h *NoteHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	defer h.Logger.Sync()
 	vars := mux.Vars(r)
 	id := vars["id"]
+	ctx, cancel := h.opContext(r)
+	defer cancel()
 	// delete
-	if err := h.Repository.Delete(id); err != nil {
+	if err := h.Repository.Delete(ctx, id); err != nil {
 		h.Logger.Error(err.Error(),
 			zap.String("note id", id),
 			zap.String("url", r.URL.String()),
 		)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 	h.Logger.Info("deleted note",
@@ -164,3 +318,40 @@ func (h *NoteHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	)
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// opContext returns the request context, which is cancelled when the
+// client goes away, with the deadline for the repository calls
+func (h *NoteHandler) opContext(r *http.Request) (context.Context, context.CancelFunc) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// errorStatus returns 504 for a repository call which ran out of time
+// and 500 for other failures
+func errorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// parseNoteQuery reads the list parameters of GetAll
+func parseNoteQuery(v url.Values) (model.NoteQuery, error) {
+	query := model.NoteQuery{
+		Text:   v.Get("q"),
+		Tag:    v.Get("tag"),
+		Sort:   v.Get("sort"),
+		Cursor: v.Get("cursor"),
+	}
+	if limit := v.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return query, model.ErrInvalidQuery
+		}
+		query.Limit = n
+	}
+	return query.Normalize()
+}
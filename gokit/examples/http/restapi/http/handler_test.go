@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"go.uber.org/zap"
@@ -8,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
@@ -47,7 +49,7 @@ func setUp(t *testing.T) {
 
 func TestNoteHandler_Post_Valid_Note(t *testing.T) {
 	setUp(t)
-	mockRepository.EXPECT().Create(getMockNote()).Return(nil).Times(1)
+	mockRepository.EXPECT().Create(gomock.Any(), getMockNote()).Return(nil).Times(1)
 	noteJson := `{"title": "mux", "description": "Gorilla mux is a router library"}`
 	r.HandleFunc("/api/notes", handler.Post).Methods("POST")
 	req, err := http.NewRequest(
@@ -70,8 +72,8 @@ func TestNoteHandler_Post_Valid_Note(t *testing.T) {
 
 func TestNoteHandler_Post_Duplicate_Note_Title(t *testing.T) {
 	setUp(t)
-	mockRepository.EXPECT().Create(getMockNote()).Return(model.ErrNoteExists).Times(2)
-	mockRepository.Create(getMockNote())
+	mockRepository.EXPECT().Create(gomock.Any(), getMockNote()).Return(model.ErrNoteExists).Times(2)
+	mockRepository.Create(context.Background(), getMockNote())
 	noteJson := `{"title": "mux", "description": "Gorilla mux is a router library"}`
 	r.HandleFunc("/api/notes", handler.Post).Methods("POST")
 	req, err := http.NewRequest(
@@ -94,7 +96,7 @@ func TestNoteHandler_Post_Duplicate_Note_Title(t *testing.T) {
 func TestNoteHandler_GetAll(t *testing.T) {
 	setUp(t)
 	mockNotes := getMockNotes()
-	mockRepository.EXPECT().GetAll().Return(mockNotes, nil)
+	mockRepository.EXPECT().Find(gomock.Any(), defaultQuery()).Return(model.NotePage{Notes: mockNotes}, nil)
 	r.HandleFunc("/api/notes", handler.GetAll).Methods("GET")
 	req, err := http.NewRequest(
 		"GET",
@@ -145,3 +147,186 @@ func getMockNotes() []model.Note {
 	}
 	return notes
 }
+
+func TestNoteHandler_Get_ETag(t *testing.T) {
+	setUp(t)
+	note := getMockNote()
+	note.NoteID = "1"
+	note.Version = 2
+	mockRepository.EXPECT().GetById(gomock.Any(), "1").Return(note, nil)
+	r.HandleFunc("/api/notes/{id}", handler.Get).Methods("GET")
+	req := httptest.NewRequest("GET", "/api/notes/1", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
+
+func TestNoteHandler_Put_If_Match(t *testing.T) {
+	setUp(t)
+	note := getMockNote()
+	note.Version = 2
+	updated := note
+	updated.Version = 3
+	mockRepository.EXPECT().Update(gomock.Any(), "1", note).Return(updated, nil)
+	r.HandleFunc("/api/notes/{id}", handler.Put).Methods("PUT")
+	// The version in the body is ignored in favour of If-Match
+	noteJson := `{"title": "mux", "description": "Gorilla mux is a router library", "version": 7}`
+	req := httptest.NewRequest("PUT", "/api/notes/1", strings.NewReader(noteJson))
+	req.Header.Set("If-Match", `"2"`)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+}
+
+func TestNoteHandler_Put_Version_Conflict(t *testing.T) {
+	setUp(t)
+	note := getMockNote()
+	note.Version = 1
+	mockRepository.EXPECT().Update(gomock.Any(), "1", note).Return(model.Note{}, model.ErrVersionConflict)
+	r.HandleFunc("/api/notes/{id}", handler.Put).Methods("PUT")
+	noteJson := `{"title": "mux", "description": "Gorilla mux is a router library"}`
+	req := httptest.NewRequest("PUT", "/api/notes/1", strings.NewReader(noteJson))
+	req.Header.Set("If-Match", `"1"`)
+	r.ServeHTTP(w, req)
+	assert.Equal(
+		t,
+		http.StatusPreconditionFailed,
+		w.Code,
+		fmt.Sprintf("HTTP Status expected: %d, got: %d", http.StatusPreconditionFailed, w.Code),
+	)
+
+	// Weak ETags never match
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("PUT", "/api/notes/1", strings.NewReader(noteJson))
+	req.Header.Set("If-Match", `W/"1"`)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestNoteHandler_Patch(t *testing.T) {
+	setUp(t)
+	current := getMockNote()
+	current.NoteID = "1"
+	current.Version = 4
+	patched := current
+	patched.Description = ""
+	updated := patched
+	updated.Version = 5
+	gomock.InOrder(
+		mockRepository.EXPECT().GetById(gomock.Any(), "1").Return(current, nil),
+		mockRepository.EXPECT().Update(gomock.Any(), "1", patched).Return(updated, nil),
+	)
+	r.HandleFunc("/api/notes/{id}", handler.Patch).Methods("PATCH")
+	req := httptest.NewRequest("PATCH", "/api/notes/1", strings.NewReader(`{"description": null, "version": 1}`))
+	req.Header.Set("Content-Type", MergePatchContentType)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+}
+
+func TestNoteHandler_Patch_Stale_If_Match(t *testing.T) {
+	setUp(t)
+	current := getMockNote()
+	current.Version = 4
+	mockRepository.EXPECT().GetById(gomock.Any(), "1").Return(current, nil)
+	r.HandleFunc("/api/notes/{id}", handler.Patch).Methods("PATCH")
+	req := httptest.NewRequest("PATCH", "/api/notes/1", strings.NewReader(`{"title": "gorilla/mux"}`))
+	req.Header.Set("Content-Type", MergePatchContentType)
+	req.Header.Set("If-Match", `"3"`)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("PATCH", "/api/notes/1", strings.NewReader(`{"title": "gorilla/mux"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from appendix A of RFC 7386
+	tests := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		var target, patch interface{}
+		json.Unmarshal([]byte(tt.target), &target)
+		json.Unmarshal([]byte(tt.patch), &patch)
+		got, _ := json.Marshal(mergePatch(target, patch))
+		assert.JSONEq(t, tt.want, string(got), "target %s, patch %s", tt.target, tt.patch)
+	}
+}
+
+func TestNoteHandler_GetAll_Timeout(t *testing.T) {
+	setUp(t)
+	handler.Timeout = 10 * time.Millisecond
+	// A repository call that only returns when its context is done
+	mockRepository.EXPECT().Find(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, q model.NoteQuery) (model.NotePage, error) {
+		<-ctx.Done()
+		return model.NotePage{}, ctx.Err()
+	})
+	r.HandleFunc("/api/notes", handler.GetAll).Methods("GET")
+	req := httptest.NewRequest("GET", "/api/notes", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(
+		t,
+		http.StatusGatewayTimeout,
+		w.Code,
+		fmt.Sprintf("HTTP Status expected: %d, got: %d", http.StatusGatewayTimeout, w.Code),
+	)
+}
+
+func TestNoteHandler_GetAll_Query(t *testing.T) {
+	setUp(t)
+	query := model.NoteQuery{Text: "router", Tag: "go", Sort: "title", Limit: 1, Cursor: "abc"}
+	mockRepository.EXPECT().Find(gomock.Any(), query).Return(model.NotePage{Notes: getMockNotes()[:1], Next: "def"}, nil)
+	r.HandleFunc("/api/notes", handler.GetAll).Methods("GET")
+	req := httptest.NewRequest("GET", "/api/notes?q=router&tag=Go&sort=title&limit=1&cursor=abc", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `</api/notes?cursor=def&limit=1&q=router&sort=title&tag=Go>; rel="next"`, w.Header().Get("Link"))
+	var notes []model.Note
+	json.Unmarshal(w.Body.Bytes(), &notes)
+	assert.Equal(t, getMockNotes()[:1], notes)
+
+	// No Link on the last page
+	mockRepository.EXPECT().Find(gomock.Any(), defaultQuery()).Return(model.NotePage{Notes: []model.Note{}}, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/notes", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Link"))
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestNoteHandler_GetAll_Invalid_Query(t *testing.T) {
+	setUp(t)
+	r.HandleFunc("/api/notes", handler.GetAll).Methods("GET")
+	// Rejected before reaching the repository
+	for _, url := range []string{"/api/notes?sort=description", "/api/notes?limit=-1", "/api/notes?limit=ten"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+
+	mockRepository.EXPECT().Find(gomock.Any(), gomock.Any()).Return(model.NotePage{}, model.ErrInvalidCursor)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/notes?cursor=bogus", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func defaultQuery() model.NoteQuery {
+	return model.NoteQuery{Sort: model.DefaultSort, Limit: model.DefaultLimit}
+}
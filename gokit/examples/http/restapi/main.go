@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	// external
 	"github.com/gorilla/mux"
@@ -10,19 +15,26 @@ import (
 
 	apphttp "github.com/shijuvar/gokit/examples/http/restapi/http"
 	"github.com/shijuvar/gokit/examples/http/restapi/memstore"
+	"github.com/shijuvar/gokit/examples/http/restapi/model"
+	"github.com/shijuvar/gokit/examples/http/restapi/mongodb"
 )
 
 // Entry point of the program
 func main() {
-	logger, _ := zap.NewProduction()              // Create Uber's Zap logger
-	repo, err := memstore.NewInmemoryRepository() // With in-memory database
-	//repo, err := mongodb.NewMongoNoteRepository() // With MongoDB database
+	logger, _ := zap.NewProduction() // Create Uber's Zap logger
+	repo, closeRepo, err := newRepository()
+	if err != nil {
+		log.Fatal("Error:", err)
+	}
+	// Deadline of the repository calls of a request, e.g. "2s"
+	timeout, err := time.ParseDuration(getEnv("NOTES_DB_TIMEOUT", "5s"))
 	if err != nil {
 		log.Fatal("Error:", err)
 	}
 	h := &apphttp.NoteHandler{
 		Repository: repo, // Injecting dependency
 		Logger:     logger,
+		Timeout:    timeout,
 	}
 	router := initializeRoutes(h) // configure routes
 
@@ -30,8 +42,60 @@ func main() {
 		Addr:    ":8080",
 		Handler: router,
 	}
-	log.Println("Listening...")
-	server.ListenAndServe() // Run the http server
+	// Stop on SIGINT/SIGTERM, so the notes are saved before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Println("Listening...")
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed { // Run the http server
+			log.Println("Error:", err)
+			stop()
+		}
+	}()
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Shutdown returns once the in-flight requests are done, so none of
+	// them writes to the repository after it is closed
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Error:", err)
+	}
+	if err := closeRepo(shutdownCtx); err != nil {
+		log.Fatal("Error:", err)
+	}
+}
+
+// newRepository returns the MongoDB repository if NOTES_MONGO_URI is
+// set, otherwise the in-memory repository, saved in the directory of
+// NOTES_DATA_DIR if set
+func newRepository() (model.Repository, func(context.Context) error, error) {
+	if uri := os.Getenv("NOTES_MONGO_URI"); uri != "" {
+		repo, err := mongodb.NewMongoNoteRepository(context.Background(), mongodb.Config{ // With MongoDB database
+			URI:        uri,
+			Database:   os.Getenv("NOTES_MONGO_DATABASE"),
+			Collection: os.Getenv("NOTES_MONGO_COLLECTION"),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, repo.Close, nil
+	}
+	if dir := os.Getenv("NOTES_DATA_DIR"); dir != "" {
+		repo, err := memstore.NewPersistentRepository(dir, memstore.Options{}) // With notes kept on disk
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, func(context.Context) error { return repo.Close() }, nil
+	}
+	repo, err := memstore.NewInmemoryRepository() // With in-memory database
+	return repo, func(context.Context) error { return nil }, err
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
 
 func initializeRoutes(h *apphttp.NoteHandler) *mux.Router {
@@ -40,6 +104,7 @@ func initializeRoutes(h *apphttp.NoteHandler) *mux.Router {
 	r.HandleFunc("/api/notes/{id}", h.Get).Methods("GET")
 	r.HandleFunc("/api/notes", h.Post).Methods("POST")
 	r.HandleFunc("/api/notes/{id}", h.Put).Methods("PUT")
+	r.HandleFunc("/api/notes/{id}", h.Patch).Methods("PATCH")
 	r.HandleFunc("/api/notes/{id}", h.Delete).Methods("DELETE")
 	return r
 }
@@ -2,7 +2,10 @@ package memstore
 
 import (
 	// internal
+	"context"
 	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	// external
@@ -12,70 +15,164 @@ import (
 )
 
 // inmemoryRepository provides concrete implementation
-// for repository interface
+// for repository interface. It is safe for concurrent use.
 type inmemoryRepository struct {
+	mu        sync.RWMutex
 	noteStore map[string]model.Note
+	titles    map[string]string // title to NoteID, keeps titles unique
+	log       *diskLog          // nil unless the notes are persisted
 }
 
 func NewInmemoryRepository() (model.Repository, error) {
+	return newInmemoryRepository(), nil
+}
+
+func newInmemoryRepository() *inmemoryRepository {
 	return &inmemoryRepository{
 		noteStore: make(map[string]model.Note),
-	}, nil
+		titles:    make(map[string]string),
+	}
 }
 
 func (i *inmemoryRepository) isNoteTitleExists(title string) bool {
-	for _, v := range i.noteStore {
-		if v.Title == title {
-			return true
-		}
-	}
-	return false
+	_, ok := i.titles[title]
+	return ok
 }
-func (i *inmemoryRepository) Create(n model.Note) error {
+func (i *inmemoryRepository) Create(ctx context.Context, n model.Note) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	if _, ok := i.noteStore[n.NoteID]; ok {
 		return errors.New("NoteID exists")
 	}
 	if i.isNoteTitleExists(n.Title) {
 		return model.ErrNoteExists
 	}
+	n.Tags = model.NormalizeTags(n.Tags)
 	n.CreatedOn = time.Now()
+	n.UpdatedOn = n.CreatedOn
+	n.Version = 1
 	// Create a Version 4 UUID.
 	uid, _ := uuid.NewV4()
 	n.NoteID = uid.String()
-	i.noteStore[n.NoteID] = n
-	return nil
+	return i.commit(record{Op: opPut, Note: &n})
 }
 
-func (i *inmemoryRepository) Update(id string, n model.Note) error {
-	if _, ok := i.noteStore[id]; !ok {
-		return errors.New("NoteID doesn't exist")
+func (i *inmemoryRepository) Update(ctx context.Context, id string, n model.Note) (model.Note, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	existing, ok := i.noteStore[id]
+	if !ok {
+		return model.Note{}, model.ErrNotFound
 	}
-	n.NoteID = id
-	n.CreatedOn = time.Now()
-	i.noteStore[id] = n
-	return nil
+	if n.Version != 0 && n.Version != existing.Version {
+		return model.Note{}, model.ErrVersionConflict
+	}
+	if n.Title != existing.Title && i.isNoteTitleExists(n.Title) {
+		return model.Note{}, model.ErrNoteExists
+	}
+	existing.Title = n.Title
+	existing.Description = n.Description
+	existing.Tags = model.NormalizeTags(n.Tags)
+	existing.UpdatedOn = time.Now()
+	existing.Version++
+	if err := i.commit(record{Op: opPut, Note: &existing}); err != nil {
+		return model.Note{}, err
+	}
+	return existing, nil
 }
 
-func (i *inmemoryRepository) Delete(id string) error {
+func (i *inmemoryRepository) Delete(ctx context.Context, id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	if _, ok := i.noteStore[id]; !ok {
-		return errors.New("NoteID doesn't exist")
+		return model.ErrNotFound
 	}
-	delete(i.noteStore, id)
-	return nil
+	return i.commit(record{Op: opDelete, NoteID: id})
 }
-func (i *inmemoryRepository) GetById(id string) (model.Note, error) {
+func (i *inmemoryRepository) GetById(ctx context.Context, id string) (model.Note, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	if v, ok := i.noteStore[id]; !ok {
-		return model.Note{}, errors.New("NoteID doesn't exist")
+		return model.Note{}, model.ErrNotFound
 	} else {
 		return v, nil
 	}
 
 }
 
-func (i *inmemoryRepository) GetAll() ([]model.Note, error) {
+func (i *inmemoryRepository) GetAll(ctx context.Context) ([]model.Note, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
 	notes := make([]model.Note, 0, len(i.noteStore))
 	for _, v := range i.noteStore {
 		notes = append(notes, v)
 	}
 	return notes, nil
 }
+
+func (i *inmemoryRepository) Find(ctx context.Context, q model.NoteQuery) (model.NotePage, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return model.NotePage{}, err
+	}
+	var cursor *model.Cursor
+	if q.Cursor != "" {
+		c, err := q.DecodeCursor()
+		if err != nil {
+			return model.NotePage{}, err
+		}
+		cursor = &c
+	}
+	i.mu.RLock()
+	notes := []model.Note{}
+	for _, n := range i.noteStore {
+		if q.Matches(n) && (cursor == nil || cursor.After(n)) {
+			notes = append(notes, n)
+		}
+	}
+	i.mu.RUnlock()
+	sort.Slice(notes, func(a, b int) bool {
+		return model.Compare(notes[a], notes[b], q.Sort) < 0
+	})
+	page := model.NotePage{Notes: notes}
+	if len(notes) > q.Limit {
+		page.Notes = notes[:q.Limit]
+		page.Next = model.CursorAfter(page.Notes[q.Limit-1], q.Sort).Encode()
+	}
+	return page, nil
+}
+
+// commit writes r to the log, if any, and applies it. The caller must
+// hold the write lock.
+func (i *inmemoryRepository) commit(r record) error {
+	if i.log != nil {
+		if err := i.log.append(r); err != nil {
+			return err
+		}
+	}
+	i.apply(r)
+	if i.log != nil && i.log.due() {
+		// The change is already in the log, so a failed snapshot only
+		// means the log keeps growing until the next one succeeds
+		i.log.snapshot(i.noteStore)
+	}
+	return nil
+}
+
+// apply changes the notes and the title index; it is also used to
+// replay the log
+func (i *inmemoryRepository) apply(r record) {
+	switch r.Op {
+	case opPut:
+		if old, ok := i.noteStore[r.Note.NoteID]; ok {
+			delete(i.titles, old.Title)
+		}
+		i.noteStore[r.Note.NoteID] = *r.Note
+		i.titles[r.Note.Title] = r.Note.NoteID
+	case opDelete:
+		if old, ok := i.noteStore[r.NoteID]; ok {
+			delete(i.titles, old.Title)
+			delete(i.noteStore, r.NoteID)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shijuvar/gokit/examples/http/restapi/model"
+)
+
+func TestInmemoryRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := NewInmemoryRepository()
+	repo.Create(ctx, model.Note{Title: "mux", Description: "Gorilla mux"})
+	repo.Create(ctx, model.Note{Title: "zap", Description: "Uber zap"})
+	notes, _ := repo.GetAll(ctx)
+	var note model.Note
+	for _, n := range notes {
+		if n.Title == "mux" {
+			note = n
+		}
+	}
+	assert.Equal(t, int64(1), note.Version)
+	assert.Equal(t, note.CreatedOn, note.UpdatedOn)
+
+	updated, err := repo.Update(ctx, note.NoteID, model.Note{Title: "mux", Description: "A router", Version: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, note.CreatedOn, updated.CreatedOn, "CreatedOn must not change")
+	assert.True(t, updated.UpdatedOn.After(note.UpdatedOn) || updated.UpdatedOn.Equal(note.UpdatedOn))
+
+	// A stale version is rejected
+	_, err = repo.Update(ctx, note.NoteID, model.Note{Title: "mux", Description: "Lost update", Version: 1})
+	assert.Equal(t, model.ErrVersionConflict, err)
+	// So is the title of another note
+	_, err = repo.Update(ctx, note.NoteID, model.Note{Title: "zap"})
+	assert.Equal(t, model.ErrNoteExists, err)
+	_, err = repo.Update(ctx, "missing", model.Note{Title: "mux"})
+	assert.Equal(t, model.ErrNotFound, err)
+
+	got, _ := repo.GetById(ctx, note.NoteID)
+	assert.Equal(t, "A router", got.Description)
+}
+
+func TestInmemoryRepository_Find(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := NewInmemoryRepository()
+	for _, n := range []model.Note{
+		{Title: "mux", Description: "Gorilla mux is a router library", Tags: []string{"Go", "http", "go"}},
+		{Title: "zap", Description: "Uber zap is a logging package", Tags: []string{"go"}},
+		{Title: "echo", Description: "High performance web framework", Tags: []string{"go", "http"}},
+		{Title: "express", Description: "Web framework for Node.js", Tags: []string{"js", "http"}},
+		{Title: "chi", Description: "Lightweight router", Tags: []string{"go", "http"}},
+	} {
+		assert.NoError(t, repo.Create(ctx, n))
+	}
+
+	// All notes by title, two at a time
+	var titles []string
+	query := model.NoteQuery{Sort: "title", Limit: 2}
+	for pages := 0; ; pages++ {
+		page, err := repo.Find(ctx, query)
+		assert.NoError(t, err)
+		for _, n := range page.Notes {
+			titles = append(titles, n.Title)
+		}
+		if page.Next == "" {
+			assert.Equal(t, 2, pages)
+			break
+		}
+		query.Cursor = page.Next
+	}
+	assert.Equal(t, []string{"chi", "echo", "express", "mux", "zap"}, titles)
+
+	page, err := repo.Find(ctx, model.NoteQuery{Tag: "HTTP", Text: "Framework", Sort: "-title"})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Notes, 2) {
+		assert.Equal(t, "express", page.Notes[0].Title)
+		assert.Equal(t, "echo", page.Notes[1].Title)
+	}
+	assert.Empty(t, page.Next)
+
+	page, _ = repo.Find(ctx, model.NoteQuery{Text: "router"})
+	assert.Len(t, page.Notes, 2)
+	mux, _ := repo.GetById(ctx, noteByTitle(t, repo, "mux").NoteID)
+	assert.Equal(t, []string{"go", "http"}, mux.Tags, "tags are normalized")
+
+	// A cursor only works with the sort it was made for
+	_, err = repo.Find(ctx, model.NoteQuery{Sort: "-createdon", Cursor: query.Cursor})
+	assert.Equal(t, model.ErrInvalidCursor, err)
+	_, err = repo.Find(ctx, model.NoteQuery{Sort: "description"})
+	assert.Equal(t, model.ErrInvalidQuery, err)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -11,20 +12,32 @@ import (
 
 var ErrNotFound = errors.New("No records found")
 var ErrNoteExists = errors.New("Note title exists")
+var ErrVersionConflict = errors.New("Note has been modified")
 
 type Note struct {
 	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
 	NoteID      string             `json:"noteid,omitempty" bson:"noteid,omitempty"`
 	Title       string             `json:"title" bson:"title"`
 	Description string             `json:"description" bson:"description"`
+	Tags        []string           `json:"tags,omitempty" bson:"tags,omitempty"`
 	CreatedOn   time.Time          `json:"createdon,omitempty" bson:"createdon,omitempty"`
+	UpdatedOn   time.Time          `json:"updatedon,omitempty" bson:"updatedon,omitempty"`
+	// Version starts at 1 and is incremented by every update
+	Version int64 `json:"version" bson:"version"`
 }
 
-// CRUD interface
+// CRUD interface. The context bounds each call and is cancelled when the
+// client goes away.
 type Repository interface {
-	Create(Note) error
-	Update(string, Note) error
-	Delete(string) error
-	GetById(string) (Note, error)
-	GetAll() ([]Note, error)
+	Create(context.Context, Note) error
+	// Update changes the title, description and tags of a note and returns the
+	// updated note. A non-zero n.Version must match the stored version,
+	// otherwise ErrVersionConflict is returned.
+	Update(ctx context.Context, id string, n Note) (Note, error)
+	Delete(context.Context, string) error
+	GetById(context.Context, string) (Note, error)
+	GetAll(context.Context) ([]Note, error)
+	// Find returns the page of notes selected by q, in the order of
+	// q.Sort. It returns ErrInvalidQuery or ErrInvalidCursor for a bad q.
+	Find(context.Context, NoteQuery) (NotePage, error)
 }
@@ -0,0 +1,189 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidQuery = errors.New("Invalid query")
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+const (
+	// DefaultLimit is the page size when NoteQuery.Limit is not set
+	DefaultLimit = 20
+	// MaxLimit is the largest page size
+	MaxLimit = 100
+	// DefaultSort is the order when NoteQuery.Sort is not set
+	DefaultSort = "-createdon"
+)
+
+// sortFields are the fields notes can be sorted by, ascending or, with a
+// "-" prefix, descending. Notes with equal values are ordered by NoteID.
+var sortFields = map[string]bool{"createdon": true, "updatedon": true, "title": true}
+
+// NoteQuery selects a page of notes
+type NoteQuery struct {
+	Text   string // case-insensitive substring of the title or description
+	Tag    string
+	Sort   string // field, or -field for descending order
+	Limit  int
+	Cursor string // Next of the previous page
+}
+
+// NotePage is a page of notes; Next is empty on the last page
+type NotePage struct {
+	Notes []Note
+	Next  string
+}
+
+// Normalize applies the defaults of q and checks its values
+func (q NoteQuery) Normalize() (NoteQuery, error) {
+	q.Text = strings.TrimSpace(q.Text)
+	q.Tag = NormalizeTag(q.Tag)
+	if q.Sort == "" {
+		q.Sort = DefaultSort
+	}
+	if !sortFields[strings.TrimPrefix(q.Sort, "-")] {
+		return q, ErrInvalidQuery
+	}
+	if q.Limit < 0 {
+		return q, ErrInvalidQuery
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	return q, nil
+}
+
+// SortField returns the field of q.Sort and whether it is descending
+func (q NoteQuery) SortField() (string, bool) {
+	return strings.TrimPrefix(q.Sort, "-"), strings.HasPrefix(q.Sort, "-")
+}
+
+// Matches reports whether n matches the text and tag of q
+func (q NoteQuery) Matches(n Note) bool {
+	if q.Tag != "" && !n.HasTag(q.Tag) {
+		return false
+	}
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		return strings.Contains(strings.ToLower(n.Title), text) ||
+			strings.Contains(strings.ToLower(n.Description), text)
+	}
+	return true
+}
+
+// HasTag reports whether n is tagged with tag
+func (n Note) HasTag(tag string) bool {
+	for _, t := range n.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTag trims and lower-cases a tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// NormalizeTags normalizes tags and drops empty and repeated ones
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// Cursor is the position after the last note of a page
+type Cursor struct {
+	Sort   string    `json:"s"`
+	Title  string    `json:"t,omitempty"`
+	Time   time.Time `json:"d,omitempty"` // CreatedOn or UpdatedOn
+	NoteID string    `json:"id"`
+}
+
+// CursorAfter returns the cursor following n in the order of sort
+func CursorAfter(n Note, sort string) Cursor {
+	c := Cursor{Sort: sort, NoteID: n.NoteID}
+	switch strings.TrimPrefix(sort, "-") {
+	case "title":
+		c.Title = n.Title
+	case "createdon":
+		c.Time = n.CreatedOn
+	case "updatedon":
+		c.Time = n.UpdatedOn
+	}
+	return c
+}
+
+// After reports whether n comes after the position of c
+func (c Cursor) After(n Note) bool {
+	pivot := Note{Title: c.Title, CreatedOn: c.Time, UpdatedOn: c.Time, NoteID: c.NoteID}
+	return Compare(n, pivot, c.Sort) > 0
+}
+
+// Encode returns the opaque form of c used in NotePage.Next
+func (c Cursor) Encode() string {
+	j, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(j)
+}
+
+// DecodeCursor parses q.Cursor, which must have been made for q.Sort
+func (q NoteQuery) DecodeCursor() (Cursor, error) {
+	var c Cursor
+	j, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(j, &c); err != nil || c.Sort != q.Sort || c.NoteID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Compare orders notes a and b by sort, then by NoteID. It returns a
+// negative number if a comes first.
+func Compare(a, b Note, sort string) int {
+	field := strings.TrimPrefix(sort, "-")
+	var cmp int
+	switch field {
+	case "title":
+		cmp = strings.Compare(a.Title, b.Title)
+	case "createdon":
+		cmp = compareTime(a.CreatedOn, b.CreatedOn)
+	case "updatedon":
+		cmp = compareTime(a.UpdatedOn, b.UpdatedOn)
+	}
+	if strings.HasPrefix(sort, "-") {
+		cmp = -cmp
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.NoteID, b.NoteID)
+	}
+	return cmp
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -14,44 +15,91 @@ import (
 	"github.com/shijuvar/gokit/examples/http/restapi/model"
 )
 
-var ctx = context.TODO()
-var mongoClient *mongo.Client
+// Config holds the connection settings of MongoNoteRepository
+type Config struct {
+	URI        string // e.g. "mongodb://localhost:27017/"
+	Database   string // defaults to "notesdb"
+	Collection string // defaults to "notes"
+	// ConnectTimeout bounds connecting and the initial ping (default 10s)
+	ConnectTimeout time.Duration
+}
 
 type MongoNoteRepository struct {
+	client         *mongo.Client
 	noteCollection *mongo.Collection
 }
 
-func NewMongoNoteRepository() (model.Repository, error) {
-	if mongoClient == nil {
-		clientOptions := options.Client().ApplyURI("mongodb://localhost:27017/")
-		var err error
-		mongoClient, err = mongo.Connect(ctx, clientOptions)
-		if err != nil {
-			return nil, err
-		}
-		err = mongoClient.Ping(ctx, nil)
-		if err != nil {
-			return nil, err
-		}
+var _ model.Repository = (*MongoNoteRepository)(nil)
+
+// NewMongoNoteRepository connects to MongoDB and checks the connection.
+// The repository must be closed to disconnect.
+func NewMongoNoteRepository(ctx context.Context, cfg Config) (*MongoNoteRepository, error) {
+	if cfg.URI == "" {
+		return nil, errors.New("mongodb: URI is required")
+	}
+	if cfg.Database == "" {
+		cfg.Database = "notesdb"
+	}
+	if cfg.Collection == "" {
+		cfg.Collection = "notes"
+	}
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+	clientOptions := options.Client().ApplyURI(cfg.URI)
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	collection := client.Database(cfg.Database).Collection(cfg.Collection)
+	if err := ensureIndexes(ctx, collection); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
 	}
-	collection := mongoClient.Database("notesdb").Collection("notes")
-	return &MongoNoteRepository{noteCollection: collection}, nil
+	return &MongoNoteRepository{client: client, noteCollection: collection}, nil
 }
 
-func (m *MongoNoteRepository) Create(n model.Note) error {
-	if en, _ := getNoteByTitle(m.noteCollection, n.Title); en.Title == n.Title {
+// ensureIndexes creates the indexes serving the sort orders and the tag
+// filter of Find
+func ensureIndexes(ctx context.Context, c *mongo.Collection) error {
+	_, err := c.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"createdon", 1}, {"noteid", 1}}},
+		{Keys: bson.D{{"updatedon", 1}, {"noteid", 1}}},
+		{Keys: bson.D{{"title", 1}, {"noteid", 1}}},
+		{Keys: bson.D{{"tags", 1}}},
+	})
+	return err
+}
+
+// Close disconnects from MongoDB, waiting for in-use connections until
+// ctx is done
+func (m *MongoNoteRepository) Close(ctx context.Context) error {
+	return m.client.Disconnect(ctx)
+}
+
+func (m *MongoNoteRepository) Create(ctx context.Context, n model.Note) error {
+	if en, _ := getNoteByTitle(ctx, m.noteCollection, n.Title); en.Title == n.Title {
 		return model.ErrNoteExists
 	}
 	// Create a Version 4 UUID.
 	uid, _ := uuid.NewV4()
 	n.NoteID = uid.String()
+	n.Tags = model.NormalizeTags(n.Tags)
 	n.CreatedOn = time.Now()
+	n.UpdatedOn = n.CreatedOn
+	n.Version = 1
 	n.ID = primitive.NewObjectID()
 	_, err := m.noteCollection.InsertOne(ctx, n)
 	return err
 }
 
-func getNoteByTitle(c *mongo.Collection, title string) (model.Note, error) {
+func getNoteByTitle(ctx context.Context, c *mongo.Collection, title string) (model.Note, error) {
 	filter := bson.D{{"title", title}}
 	var n model.Note
 	err := c.FindOne(ctx, filter).Decode(&n)
@@ -65,30 +113,56 @@ func getNoteByTitle(c *mongo.Collection, title string) (model.Note, error) {
 	return n, nil
 }
 
-func (m *MongoNoteRepository) Update(id string, n model.Note) error {
-	if _, err := m.GetById(id); errors.Is(err, model.ErrNotFound) {
-		return err
+func (m *MongoNoteRepository) Update(ctx context.Context, id string, n model.Note) (model.Note, error) {
+	en, err := getNoteByTitle(ctx, m.noteCollection, n.Title)
+	if err == nil && en.NoteID != id {
+		return model.Note{}, model.ErrNoteExists
 	}
+	if err != nil && err != model.ErrNotFound {
+		return model.Note{}, err
+	}
+	// The version in the filter makes the check and the update atomic
 	filter := bson.D{{"noteid", id}}
-	update := bson.D{{"$set",
-		bson.D{
+	if n.Version != 0 {
+		filter = append(filter, bson.E{"version", n.Version})
+	}
+	update := bson.D{
+		{"$set", bson.D{
 			{"title", n.Title},
 			{"description", n.Description},
-		},
-	}}
-	_, err := m.noteCollection.UpdateOne(ctx, filter, update)
-	return err
+			{"tags", model.NormalizeTags(n.Tags)},
+			{"updatedon", time.Now()},
+		}},
+		{"$inc", bson.D{{"version", 1}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated model.Note
+	err = m.noteCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		// Either the note is gone or its version has moved on
+		if _, err := m.GetById(ctx, id); err != nil {
+			return model.Note{}, err
+		}
+		return model.Note{}, model.ErrVersionConflict
+	}
+	if err != nil {
+		return model.Note{}, err
+	}
+	return updated, nil
 }
-func (m *MongoNoteRepository) Delete(id string) error {
-	if _, err := m.GetById(id); errors.Is(err, model.ErrNotFound) {
+func (m *MongoNoteRepository) Delete(ctx context.Context, id string) error {
+	filter := bson.D{{"noteid", id}}
+	result, err := m.noteCollection.DeleteOne(ctx, filter)
+	if err != nil {
 		return err
 	}
-	filter := bson.D{{"noteid", id}}
-	_, err := m.noteCollection.DeleteOne(ctx, filter)
-	return err
+	if result.DeletedCount == 0 {
+		return model.ErrNotFound
+	}
+	return nil
 }
 
-func (m *MongoNoteRepository) GetById(id string) (model.Note, error) {
+func (m *MongoNoteRepository) GetById(ctx context.Context, id string) (model.Note, error) {
 	filter := bson.D{{"noteid", id}}
 	var n model.Note
 	err := m.noteCollection.FindOne(ctx, filter).Decode(&n)
@@ -102,7 +176,7 @@ func (m *MongoNoteRepository) GetById(id string) (model.Note, error) {
 	return n, nil
 }
 
-func (m *MongoNoteRepository) GetAll() ([]model.Note, error) {
+func (m *MongoNoteRepository) GetAll(ctx context.Context) ([]model.Note, error) {
 	filter := bson.D{{}}
 	var notes []model.Note
 
@@ -110,6 +184,8 @@ func (m *MongoNoteRepository) GetAll() ([]model.Note, error) {
 	if err != nil {
 		return notes, err
 	}
+	// Closed even when iterating fails or ctx is cancelled
+	defer cur.Close(context.Background())
 
 	for cur.Next(ctx) {
 		var n model.Note
@@ -125,12 +201,74 @@ func (m *MongoNoteRepository) GetAll() ([]model.Note, error) {
 		return notes, err
 	}
 
-	// once exhausted, close the cursor
-	cur.Close(ctx)
-
 	if len(notes) == 0 {
 		return notes, model.ErrNotFound
 	}
 
 	return notes, nil
 }
+
+func (m *MongoNoteRepository) Find(ctx context.Context, q model.NoteQuery) (model.NotePage, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return model.NotePage{}, err
+	}
+	field, desc := q.SortField()
+	// The text and the cursor are both $or conditions, so all conditions
+	// go into one $and
+	and := bson.A{}
+	if q.Tag != "" {
+		and = append(and, bson.D{{"tags", q.Tag}})
+	}
+	if q.Text != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q.Text), Options: "i"}
+		and = append(and, bson.D{{"$or", bson.A{
+			bson.D{{"title", pattern}},
+			bson.D{{"description", pattern}},
+		}}})
+	}
+	if q.Cursor != "" {
+		c, err := q.DecodeCursor()
+		if err != nil {
+			return model.NotePage{}, err
+		}
+		var value interface{} = c.Time
+		if field == "title" {
+			value = c.Title
+		}
+		op := "$gt"
+		if desc {
+			op = "$lt"
+		}
+		and = append(and, bson.D{{"$or", bson.A{
+			bson.D{{field, bson.D{{op, value}}}},
+			bson.D{{field, value}, {"noteid", bson.D{{"$gt", c.NoteID}}}},
+		}}})
+	}
+	filter := bson.D{}
+	if len(and) > 0 {
+		filter = bson.D{{"$and", and}}
+	}
+	direction := 1
+	if desc {
+		direction = -1
+	}
+	// One more than the page size tells whether there is a next page
+	opts := options.Find().
+		SetSort(bson.D{{field, direction}, {"noteid", 1}}).
+		SetLimit(int64(q.Limit + 1))
+	cur, err := m.noteCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return model.NotePage{}, err
+	}
+	notes := []model.Note{}
+	if err := cur.All(ctx, &notes); err != nil {
+		return model.NotePage{}, err
+	}
+	page := model.NotePage{Notes: notes}
+	if len(notes) > q.Limit {
+		page.Notes = notes[:q.Limit]
+		page.Next = model.CursorAfter(page.Notes[q.Limit-1], q.Sort).Encode()
+	}
+	return page, nil
+}
@@ -7,8 +7,11 @@ import (
 
 	stan "github.com/nats-io/stan.go"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+	"github.com/shijuvar/gokit/examples/nats-streaming/relay"
 	"github.com/shijuvar/gokit/examples/nats-streaming/store"
 )
 
@@ -18,56 +21,57 @@ const (
 	clientID  = "event-store"
 )
 
-type server struct{}
+type server struct {
+	events store.EventStore
+	relay  *relay.Relay
+}
 
 // CreateOrder RPC creates a new Event into EventStore
 func (s *server) CreateEvent(ctx context.Context, in *pb.Event) (*pb.Response, error) {
-	// Persist data into EventStore database
-	command := store.EventStore{}
 	// Persist events as immutable logs into CockroachDB
-	err := command.CreateEvent(in)
-	if err != nil {
+	err := s.events.CreateEvent(in)
+	switch err {
+	case nil:
+	case store.ErrVersionConflict:
+		return nil, status.Errorf(codes.Aborted, "aggregate %s is not at version %d", in.AggregateId, in.ExpectedVersion)
+	case store.ErrEventExists:
+		return nil, status.Errorf(codes.AlreadyExists, "event %s exists", in.EventId)
+	default:
 		return nil, err
 	}
-	// Publish event on NATS Streaming Server
-	go publishEvent(in)
+	// The event is in the outbox; have it published on NATS Streaming Server
+	s.relay.Notify()
 	return &pb.Response{IsSuccess: true}, nil
 }
 
-// GetEvents RPC gets events from EventStore by given AggregateId
+// GetEvents RPC gets events from EventStore matching the given filter
 func (s *server) GetEvents(ctx context.Context, in *pb.EventFilter) (*pb.EventResponse, error) {
-	eventStore := store.EventStore{}
-	events := eventStore.GetEvents(in)
-	return &pb.EventResponse{Events: events}, nil
-}
-
-// publishEvent publish an event via NATS Streaming server
-func publishEvent(event *pb.Event) {
-	// Connect to NATS Streaming server
-	sc, err := stan.Connect(
-		clusterID,
-		clientID,
-		stan.NatsURL(stan.DefaultNatsURL),
-	)
+	events, err := s.events.GetEvents(in)
 	if err != nil {
-		log.Print(err)
-		return
+		return nil, err
 	}
-	defer sc.Close()
-	channel := event.Channel
-	eventMsg := []byte(event.EventData)
-	// Publish message on subject (channel)
-	sc.Publish(channel, eventMsg)
-	log.Println("Published message on channel: " + channel)
+	return &pb.EventResponse{Events: events}, nil
 }
 
 func main() {
+	repo, err := store.Open("postgres", store.DefaultDSN)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repo.Close()
+	// Publish the events of the outbox over one connection
+	publisher := relay.NewSTANPublisher(clusterID, clientID, stan.NatsURL(stan.DefaultNatsURL))
+	defer publisher.Close()
+	outboxRelay := relay.NewRelay(repo, publisher, relay.Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go outboxRelay.Run(ctx)
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 	// Creates a new gRPC server
 	s := grpc.NewServer()
-	pb.RegisterEventStoreServer(s, &server{})
+	pb.RegisterEventStoreServer(s, &server{events: store.NewEventStore(repo), relay: outboxRelay})
 	s.Serve(lis)
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
 	"github.com/shijuvar/gokit/examples/nats-streaming/store"
+	"github.com/shijuvar/gokit/examples/nats-streaming/subscriber"
 )
 
 const (
@@ -20,6 +21,10 @@ const (
 )
 
 func main() {
+	repo, err := store.Open("postgres", store.DefaultDSN)
+	if err != nil {
+		log.Fatal(err)
+	}
 	// Connect to NATS Streaming server
 	sc, err := stan.Connect(
 		clusterID,
@@ -30,20 +35,28 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	sc.QueueSubscribe(channel, queueGroup, func(msg *stan.Msg) {
+	// Messages are acked once the query model is synced, and an event
+	// redelivered after that is skipped
+	_, err = subscriber.Subscribe(sc, repo, subscriber.Config{
+		Channel:     channel,
+		QueueGroup:  queueGroup,
+		DurableName: durableID,
+	}, func(tx store.Tx, event *pb.Event) error {
+		if event.EventType != store.OrderCreated {
+			return nil
+		}
 		order := pb.Order{}
-		err := json.Unmarshal(msg.Data, &order)
-		if err == nil {
-			// Handle the message
-			log.Printf("Subscribed message from clientID - %s: %+v\n", clientID, order)
-			queryStore := store.QueryStore{}
-			// Perform data replication for query model into CockroachDB
-			err := queryStore.SyncOrderQueryModel(order)
-			if err != nil {
-				log.Printf("Error while replicating the query model %+v", err)
-			}
+		err := json.Unmarshal([]byte(event.EventData), &order)
+		if err != nil {
+			return err
 		}
-	}, stan.DurableName(durableID),
-	)
+		// Handle the message
+		log.Printf("Subscribed message from clientID - %s: %+v\n", clientID, order)
+		// Perform data replication for query model into CockroachDB
+		return store.CreateOrderQueryModel(tx, &order)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 	runtime.Goexit()
 }
@@ -11,6 +11,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
 )
@@ -20,6 +22,10 @@ const (
 	event     = "OrderCreated"
 	aggregate = "order"
 	grpcUri   = "localhost:50051"
+
+	// maxAttempts bounds the calls to the event store per order
+	maxAttempts  = 3
+	retryBackoff = 100 * time.Millisecond
 )
 
 func main() {
@@ -50,7 +56,7 @@ func createOrder(w http.ResponseWriter, r *http.Request) {
 	order.OrderId = aggregateID
 	order.Status = "Pending"
 	order.CreatedOn = time.Now().Unix()
-	err = createOrderRPC(order)
+	err = createOrderRPC(&order)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "Failed to create Order", 500)
@@ -62,32 +68,61 @@ func createOrder(w http.ResponseWriter, r *http.Request) {
 	w.Write(j)
 }
 
-func createOrderRPC(order pb.Order) error {
+// createOrderRPC stores the OrderCreated event of order in the event store
+func createOrderRPC(order *pb.Order) error {
 
 	conn, err := grpc.Dial(grpcUri, grpc.WithInsecure())
 	if err != nil {
 		log.Fatalf("Unable to connect: %v", err)
 	}
 	defer conn.Close()
-	client := pb.NewEventStoreClient(conn)
-	orderJSON, _ := json.Marshal(order)
+	return createOrderEvent(pb.NewEventStoreClient(conn), order)
+}
 
-	event := &pb.Event{
-		EventId:       uuid.NewV4().String(),
-		EventType:     event,
-		AggregateId:   order.OrderId,
-		AggregateType: aggregate,
-		EventData:     string(orderJSON),
-		Channel:       channel,
+// createOrderEvent stores the OrderCreated event of order through client.
+// Failed attempts are retried with backoff; on a version conflict the
+// order id is already taken by another aggregate, so order gets a new one.
+func createOrderEvent(client pb.EventStoreClient, order *pb.Order) error {
+	event := orderCreatedEvent(order)
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := client.CreateEvent(context.Background(), event)
+		if err == nil {
+			if !resp.IsSuccess {
+				return errors.New("Error from RPC server: " + resp.Error)
+			}
+			return nil
+		}
+		switch status.Code(err) {
+		case codes.AlreadyExists:
+			// An earlier attempt was stored but its response was lost
+			return nil
+		case codes.Aborted:
+			log.Printf("Order %s conflicts with an existing aggregate, retrying with a new id", order.OrderId)
+			order.OrderId = uuid.NewV4().String()
+			event = orderCreatedEvent(order)
+		case codes.Unavailable, codes.DeadlineExceeded:
+		default:
+			return errors.Wrap(err, "Error from RPC server")
+		}
+		if attempt == maxAttempts {
+			return errors.Wrapf(err, "Error from RPC server after %d attempts", attempt)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
 	}
+}
 
-	resp, err := client.CreateEvent(context.Background(), event)
-	if err != nil {
-		return errors.Wrap(err, "Error from RPC server")
-	}
-	if resp.IsSuccess {
-		return nil
-	} else {
-		return errors.Wrap(err, "Error from RPC server")
+// orderCreatedEvent returns the event starting the stream of order
+func orderCreatedEvent(order *pb.Order) *pb.Event {
+	orderJSON, _ := json.Marshal(order)
+	return &pb.Event{
+		EventId:         uuid.NewV4().String(),
+		EventType:       event,
+		AggregateId:     order.OrderId,
+		AggregateType:   aggregate,
+		EventData:       string(orderJSON),
+		Channel:         channel,
+		ExpectedVersion: 0, // a new aggregate
 	}
 }
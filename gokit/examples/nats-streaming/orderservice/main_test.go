@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+)
+
+// fakeEventStore answers the CreateEvent calls with errs, in turn, and
+// records the events it was sent
+type fakeEventStore struct {
+	pb.EventStoreClient
+	errs   []error
+	events []*pb.Event
+}
+
+func (f *fakeEventStore) CreateEvent(ctx context.Context, in *pb.Event, opts ...grpc.CallOption) (*pb.Response, error) {
+	f.events = append(f.events, in)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &pb.Response{IsSuccess: true}, nil
+}
+
+func TestCreateOrderEventRetries(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	tests := []struct {
+		name     string
+		errs     []error
+		wantErr  bool
+		attempts int
+	}{
+		{"stored", nil, false, 1},
+		{"stored after unavailable", []error{unavailable}, false, 2},
+		{"unavailable", []error{unavailable, unavailable, unavailable}, true, maxAttempts},
+		{"response lost", []error{unavailable, status.Error(codes.AlreadyExists, "event exists")}, false, 2},
+		{"invalid", []error{status.Error(codes.InvalidArgument, "bad event")}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEventStore{errs: tt.errs}
+			order := &pb.Order{OrderId: "order-1"}
+			err := createOrderEvent(client, order)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(client.events) != tt.attempts {
+				t.Fatalf("expected %d attempts, got %d", tt.attempts, len(client.events))
+			}
+			// A retry sends the same event, so the store can tell it was
+			// stored before
+			for _, e := range client.events {
+				if e.EventId != client.events[0].EventId || e.AggregateId != "order-1" {
+					t.Errorf("retry sent event %s of %s, want %s of order-1", e.EventId, e.AggregateId, client.events[0].EventId)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateOrderEventNewIdOnConflict(t *testing.T) {
+	client := &fakeEventStore{errs: []error{status.Error(codes.Aborted, "aggregate exists")}}
+	order := &pb.Order{OrderId: "order-1"}
+	if err := createOrderEvent(client, order); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.events) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(client.events))
+	}
+	first, second := client.events[0], client.events[1]
+	if order.OrderId == "order-1" || second.AggregateId != order.OrderId {
+		t.Errorf("expected the order to get a new id, got %s for aggregate %s", order.OrderId, second.AggregateId)
+	}
+	if second.EventId == first.EventId || second.ExpectedVersion != 0 {
+		t.Errorf("expected a new event for a new aggregate, got %+v", second)
+	}
+}
@@ -38,12 +38,15 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Event struct {
-	EventId       string `protobuf:"bytes,1,opt,name=event_id,json=eventId" json:"event_id,omitempty"`
-	EventType     string `protobuf:"bytes,2,opt,name=event_type,json=eventType" json:"event_type,omitempty"`
-	AggregateId   string `protobuf:"bytes,3,opt,name=aggregate_id,json=aggregateId" json:"aggregate_id,omitempty"`
-	AggregateType string `protobuf:"bytes,4,opt,name=aggregate_type,json=aggregateType" json:"aggregate_type,omitempty"`
-	EventData     string `protobuf:"bytes,5,opt,name=event_data,json=eventData" json:"event_data,omitempty"`
-	Channel       string `protobuf:"bytes,6,opt,name=channel" json:"channel,omitempty"`
+	EventId         string `protobuf:"bytes,1,opt,name=event_id,json=eventId" json:"event_id,omitempty"`
+	EventType       string `protobuf:"bytes,2,opt,name=event_type,json=eventType" json:"event_type,omitempty"`
+	AggregateId     string `protobuf:"bytes,3,opt,name=aggregate_id,json=aggregateId" json:"aggregate_id,omitempty"`
+	AggregateType   string `protobuf:"bytes,4,opt,name=aggregate_type,json=aggregateType" json:"aggregate_type,omitempty"`
+	EventData       string `protobuf:"bytes,5,opt,name=event_data,json=eventData" json:"event_data,omitempty"`
+	Channel         string `protobuf:"bytes,6,opt,name=channel" json:"channel,omitempty"`
+	Sequence        int64  `protobuf:"varint,7,opt,name=sequence" json:"sequence,omitempty"`
+	CreatedOn       int64  `protobuf:"varint,8,opt,name=created_on,json=createdOn" json:"created_on,omitempty"`
+	ExpectedVersion int64  `protobuf:"varint,9,opt,name=expected_version,json=expectedVersion" json:"expected_version,omitempty"`
 }
 
 func (m *Event) Reset()                    { *m = Event{} }
@@ -93,6 +96,27 @@ func (m *Event) GetChannel() string {
 	return ""
 }
 
+func (m *Event) GetSequence() int64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *Event) GetCreatedOn() int64 {
+	if m != nil {
+		return m.CreatedOn
+	}
+	return 0
+}
+
+func (m *Event) GetExpectedVersion() int64 {
+	if m != nil {
+		return m.ExpectedVersion
+	}
+	return 0
+}
+
 type Response struct {
 	IsSuccess bool   `protobuf:"varint,1,opt,name=is_success,json=isSuccess" json:"is_success,omitempty"`
 	Error     string `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
@@ -118,8 +142,11 @@ func (m *Response) GetError() string {
 }
 
 type EventFilter struct {
-	EventId     string `protobuf:"bytes,1,opt,name=event_id,json=eventId" json:"event_id,omitempty"`
-	AggregateId string `protobuf:"bytes,2,opt,name=aggregate_id,json=aggregateId" json:"aggregate_id,omitempty"`
+	EventId      string `protobuf:"bytes,1,opt,name=event_id,json=eventId" json:"event_id,omitempty"`
+	AggregateId  string `protobuf:"bytes,2,opt,name=aggregate_id,json=aggregateId" json:"aggregate_id,omitempty"`
+	EventType    string `protobuf:"bytes,3,opt,name=event_type,json=eventType" json:"event_type,omitempty"`
+	FromSequence int64  `protobuf:"varint,4,opt,name=from_sequence,json=fromSequence" json:"from_sequence,omitempty"`
+	ToSequence   int64  `protobuf:"varint,5,opt,name=to_sequence,json=toSequence" json:"to_sequence,omitempty"`
 }
 
 func (m *EventFilter) Reset()                    { *m = EventFilter{} }
@@ -141,6 +168,27 @@ func (m *EventFilter) GetAggregateId() string {
 	return ""
 }
 
+func (m *EventFilter) GetEventType() string {
+	if m != nil {
+		return m.EventType
+	}
+	return ""
+}
+
+func (m *EventFilter) GetFromSequence() int64 {
+	if m != nil {
+		return m.FromSequence
+	}
+	return 0
+}
+
+func (m *EventFilter) GetToSequence() int64 {
+	if m != nil {
+		return m.ToSequence
+	}
+	return 0
+}
+
 type EventResponse struct {
 	Events []*Event `protobuf:"bytes,1,rep,name=events" json:"events,omitempty"`
 }
@@ -276,24 +324,30 @@ var _EventStore_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("eventstore.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 304 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x51, 0xdd, 0x6a, 0xb3, 0x40,
-	0x10, 0xfd, 0x34, 0x9f, 0x46, 0xc7, 0xa4, 0x3f, 0x4b, 0x2f, 0xb6, 0x81, 0x42, 0x22, 0x14, 0xbc,
-	0x12, 0x6a, 0x1f, 0xa0, 0x17, 0xfd, 0x23, 0xf4, 0xce, 0xf4, 0x3e, 0xac, 0x3a, 0x58, 0x21, 0xe8,
-	0xb2, 0xbb, 0x2d, 0xe4, 0xfd, 0xfa, 0x60, 0xc5, 0x59, 0xd1, 0xd2, 0x42, 0xef, 0x76, 0xce, 0x9c,
-	0x3d, 0x67, 0xce, 0x0c, 0x9c, 0xe1, 0x07, 0xb6, 0x46, 0x9b, 0x4e, 0x61, 0x2a, 0x55, 0x67, 0x3a,
-	0xe6, 0xca, 0x22, 0xfe, 0x74, 0xc0, 0x7b, 0xec, 0x1b, 0xec, 0x12, 0x02, 0x62, 0xec, 0x9b, 0x8a,
-	0x3b, 0x6b, 0x27, 0x09, 0xf3, 0x39, 0xd5, 0xdb, 0x8a, 0x5d, 0x01, 0xd8, 0x96, 0x39, 0x4a, 0xe4,
-	0x2e, 0x35, 0x43, 0x42, 0x5e, 0x8f, 0x12, 0xd9, 0x06, 0x16, 0xa2, 0xae, 0x15, 0xd6, 0xc2, 0x60,
-	0xff, 0x7b, 0x46, 0x84, 0x68, 0xc4, 0xb6, 0x15, 0xbb, 0x86, 0x93, 0x89, 0x42, 0x2a, 0xff, 0x89,
-	0xb4, 0x1c, 0x51, 0x52, 0x1a, 0x8d, 0x2a, 0x61, 0x04, 0xf7, 0xbe, 0x19, 0x3d, 0x08, 0x23, 0x18,
-	0x87, 0x79, 0xf9, 0x26, 0xda, 0x16, 0x0f, 0xdc, 0xb7, 0x13, 0x0e, 0x65, 0x7c, 0x07, 0x41, 0x8e,
-	0x5a, 0x76, 0xad, 0x26, 0x91, 0x46, 0xef, 0xf5, 0x7b, 0x59, 0xa2, 0xd6, 0x14, 0x25, 0xc8, 0xc3,
-	0x46, 0xef, 0x2c, 0xc0, 0x2e, 0xc0, 0x43, 0xa5, 0x3a, 0x35, 0xe4, 0xb0, 0x45, 0xfc, 0x02, 0x11,
-	0xad, 0xe1, 0xa9, 0x39, 0x18, 0x54, 0x7f, 0x2d, 0xe3, 0x67, 0x5a, 0xf7, 0x57, 0xda, 0x38, 0x83,
-	0x25, 0x89, 0x8d, 0x23, 0x6d, 0xc0, 0xb7, 0xdb, 0xe7, 0xce, 0x7a, 0x96, 0x44, 0x59, 0x98, 0xca,
-	0x22, 0xb5, 0x94, 0xa1, 0x91, 0x35, 0x00, 0x04, 0xec, 0xfa, 0x03, 0xb1, 0x1b, 0x08, 0x9f, 0xd1,
-	0x10, 0xa0, 0xd9, 0xe9, 0xc8, 0xb6, 0xd3, 0xad, 0xce, 0xa7, 0xef, 0x83, 0x43, 0xfc, 0x8f, 0x25,
-	0x10, 0xdd, 0x2b, 0x14, 0x06, 0xed, 0x39, 0x27, 0x8b, 0xd5, 0xa2, 0x7f, 0x4e, 0xcc, 0xc2, 0xa7,
-	0xf3, 0xdf, 0x7e, 0x05, 0x00, 0x00, 0xff, 0xff, 0xe0, 0xcd, 0xb3, 0x6c, 0x12, 0x02, 0x00, 0x00,
+	// 393 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xcd, 0xae, 0xd3, 0x30,
+	0x10, 0x85, 0x49, 0x72, 0xd3, 0x26, 0x93, 0x96, 0x16, 0x8b, 0x85, 0xa9, 0x84, 0x68, 0x83, 0x90,
+	0xc2, 0xa6, 0x12, 0xe5, 0x01, 0x58, 0xf0, 0xa7, 0xae, 0x90, 0x52, 0xc4, 0x36, 0x72, 0x93, 0xa1,
+	0x44, 0x2a, 0x76, 0xb0, 0xdd, 0x8a, 0xbe, 0x12, 0x2b, 0x1e, 0x11, 0x65, 0x9c, 0x26, 0x50, 0xa4,
+	0xbb, 0xcb, 0x9c, 0xf3, 0x65, 0xc6, 0x3e, 0x1e, 0x98, 0xe3, 0x19, 0xa5, 0x35, 0x56, 0x69, 0x5c,
+	0x37, 0x5a, 0x59, 0xc5, 0xfc, 0x66, 0x9f, 0xfe, 0xf2, 0x21, 0x7c, 0xdf, 0x1a, 0xec, 0x09, 0x44,
+	0x44, 0x14, 0x75, 0xc5, 0xbd, 0xa5, 0x97, 0xc5, 0xf9, 0x98, 0xea, 0x6d, 0xc5, 0x9e, 0x02, 0x38,
+	0xcb, 0x5e, 0x1a, 0xe4, 0x3e, 0x99, 0x31, 0x29, 0x9f, 0x2f, 0x0d, 0xb2, 0x15, 0x4c, 0xc4, 0xe1,
+	0xa0, 0xf1, 0x20, 0x2c, 0xb6, 0x7f, 0x07, 0x04, 0x24, 0xbd, 0xb6, 0xad, 0xd8, 0x0b, 0x78, 0x38,
+	0x20, 0xd4, 0xe5, 0x8e, 0xa0, 0x69, 0xaf, 0x52, 0xa7, 0x7e, 0x50, 0x25, 0xac, 0xe0, 0xe1, 0x5f,
+	0x83, 0xde, 0x09, 0x2b, 0x18, 0x87, 0x71, 0xf9, 0x4d, 0x48, 0x89, 0x47, 0x3e, 0x72, 0x27, 0xec,
+	0x4a, 0xb6, 0x80, 0xc8, 0xe0, 0x8f, 0x13, 0xca, 0x12, 0xf9, 0x78, 0xe9, 0x65, 0x41, 0xde, 0xd7,
+	0x6d, 0xd3, 0x52, 0xa3, 0xb0, 0x58, 0x15, 0x4a, 0xf2, 0x88, 0xdc, 0xb8, 0x53, 0x3e, 0x49, 0xf6,
+	0x12, 0xe6, 0xf8, 0xb3, 0xc1, 0xb2, 0xf5, 0xcf, 0xa8, 0x4d, 0xad, 0x24, 0x8f, 0x09, 0x9a, 0x5d,
+	0xf5, 0x2f, 0x4e, 0x4e, 0xdf, 0x40, 0x94, 0xa3, 0x69, 0x94, 0x34, 0xd4, 0xb5, 0x36, 0x85, 0x39,
+	0x95, 0x25, 0x1a, 0x43, 0x81, 0x45, 0x79, 0x5c, 0x9b, 0x9d, 0x13, 0xd8, 0x63, 0x08, 0x51, 0x6b,
+	0xa5, 0xbb, 0xb4, 0x5c, 0x91, 0xfe, 0xf6, 0x20, 0xa1, 0xb4, 0x3f, 0xd4, 0x47, 0x8b, 0xfa, 0xbe,
+	0xcc, 0x6f, 0x43, 0xf5, 0xff, 0x0f, 0xf5, 0xdf, 0x67, 0x09, 0x6e, 0x9f, 0xe5, 0x39, 0x4c, 0xbf,
+	0x6a, 0xf5, 0xbd, 0xe8, 0x83, 0xb9, 0xa3, 0x5b, 0x4d, 0x5a, 0x71, 0x77, 0x0d, 0xe7, 0x19, 0x24,
+	0x56, 0x0d, 0x48, 0x48, 0x08, 0x58, 0x75, 0x05, 0xd2, 0x0d, 0x4c, 0xe9, 0xc4, 0xfd, 0xc5, 0x57,
+	0x30, 0x72, 0x9b, 0xc4, 0xbd, 0x65, 0x90, 0x25, 0x9b, 0x78, 0xdd, 0xec, 0xd7, 0x0e, 0xe9, 0x8c,
+	0x4d, 0x0d, 0x40, 0xc2, 0xae, 0x5d, 0x36, 0xf6, 0x0a, 0xe2, 0x8f, 0x68, 0x49, 0x30, 0x6c, 0xd6,
+	0xd3, 0x2e, 0x82, 0xc5, 0xa3, 0xe1, 0xf7, 0x6e, 0x42, 0xfa, 0x80, 0x65, 0x90, 0xbc, 0xa5, 0x07,
+	0x22, 0x83, 0x0d, 0x23, 0x16, 0x93, 0xf6, 0x73, 0x20, 0xf7, 0x23, 0x5a, 0xe5, 0xd7, 0x7f, 0x06,
+	0x00, 0xdf, 0x71, 0x75, 0x80, 0xde, 0x02, 0x00, 0x00,
 }
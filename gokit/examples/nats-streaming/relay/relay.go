@@ -0,0 +1,160 @@
+// Package relay publishes the events queued in the outbox of the event
+// store on NATS Streaming, each message being a protobuf encoded pb.Event.
+// An event is marked as sent only after the
+// server acknowledged it, so every event is delivered at least once, and
+// the events of an aggregate are published in sequence order.
+package relay
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+)
+
+// Outbox is the queue of events to be published
+type Outbox interface {
+	PendingOutbox(ctx context.Context, limit int) ([]*pb.Event, error)
+	MarkSent(ctx context.Context, eventID string, sentOn int64) error
+}
+
+// Publisher publishes a message and waits for the server to acknowledge it
+type Publisher interface {
+	Publish(channel string, data []byte) error
+}
+
+// Config tunes a Relay. Zero values are replaced by defaults.
+type Config struct {
+	// Poll is how often the outbox is read when nothing wakes the relay
+	// (default 1s)
+	Poll time.Duration
+	// BatchSize is the number of events read at once (default 100)
+	BatchSize int
+	// MaxBackoff bounds the wait after failed publishes, which doubles
+	// from Poll (default 30s)
+	MaxBackoff time.Duration
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Poll <= 0 {
+		cfg.Poll = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.Poll {
+		cfg.MaxBackoff = cfg.Poll
+	}
+	return cfg
+}
+
+// Relay moves events from an Outbox to a Publisher
+type Relay struct {
+	outbox    Outbox
+	publisher Publisher
+	config    Config
+	wake      chan struct{}
+}
+
+func NewRelay(outbox Outbox, publisher Publisher, cfg Config) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		publisher: publisher,
+		config:    cfg.withDefaults(),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify makes a running relay read the outbox without waiting for the
+// next poll. It never blocks.
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes pending events until ctx is done. After a failure it
+// backs off, up to Config.MaxBackoff, before trying again.
+func (r *Relay) Run(ctx context.Context) {
+	var backoff time.Duration
+	for {
+		n, err := r.RunOnce(ctx)
+		wait, wake := r.config.Poll, r.wake
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("Error while relaying the outbox: %v", err)
+			backoff *= 2
+			if backoff == 0 {
+				backoff = r.config.Poll
+			}
+			if backoff > r.config.MaxBackoff {
+				backoff = r.config.MaxBackoff
+			}
+			// A new event does not fix the failure, so only the backoff
+			// ends the wait
+			wait, wake = backoff, nil
+		case n == r.config.BatchSize:
+			// More events may be pending
+			backoff, wait = 0, 0
+		default:
+			backoff = 0
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// RunOnce publishes one batch of pending events and returns the number
+// of events published. Once an event of an aggregate fails, the later
+// events of that aggregate are left for the next run.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	events, err := r.outbox.PendingOutbox(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error on reading the outbox")
+	}
+	var (
+		sent    int
+		lastErr error
+		blocked = make(map[string]bool)
+	)
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		if blocked[e.AggregateId] {
+			continue
+		}
+		data, err := proto.Marshal(e)
+		if err == nil {
+			err = r.publisher.Publish(e.Channel, data)
+		}
+		if err != nil {
+			blocked[e.AggregateId] = true
+			lastErr = errors.Wrapf(err, "Error on publishing event %s", e.EventId)
+			continue
+		}
+		// Should this fail, the event is published again: at least once
+		if err := r.outbox.MarkSent(ctx, e.EventId, time.Now().Unix()); err != nil {
+			blocked[e.AggregateId] = true
+			lastErr = errors.Wrapf(err, "Error on marking event %s as sent", e.EventId)
+			continue
+		}
+		sent++
+	}
+	return sent, lastErr
+}
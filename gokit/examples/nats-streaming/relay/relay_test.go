@@ -0,0 +1,108 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+)
+
+// memOutbox is an Outbox of events ordered by aggregate and sequence
+type memOutbox struct {
+	events []*pb.Event
+	sent   map[string]bool
+}
+
+func (o *memOutbox) PendingOutbox(ctx context.Context, limit int) ([]*pb.Event, error) {
+	var pending []*pb.Event
+	for _, e := range o.events {
+		if !o.sent[e.EventId] && len(pending) < limit {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memOutbox) MarkSent(ctx context.Context, eventID string, sentOn int64) error {
+	o.sent[eventID] = true
+	return nil
+}
+
+// flakyPublisher fails the first publish of the events in fail
+type flakyPublisher struct {
+	fail      map[string]bool
+	published []string
+}
+
+func (p *flakyPublisher) Publish(channel string, data []byte) error {
+	var e pb.Event
+	if err := proto.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	if p.fail[e.EventId] {
+		delete(p.fail, e.EventId)
+		return errors.New("publish failed")
+	}
+	p.published = append(p.published, e.EventId)
+	return nil
+}
+
+func newOutbox(ids ...string) *memOutbox {
+	o := &memOutbox{sent: make(map[string]bool)}
+	for _, id := range ids {
+		// id is "<aggregate><sequence>"
+		o.events = append(o.events, &pb.Event{
+			EventId:     id,
+			AggregateId: id[:1],
+			Sequence:    int64(id[1] - '0'),
+			Channel:     "orders",
+			EventData:   id,
+		})
+	}
+	sort.SliceStable(o.events, func(i, j int) bool { return o.events[i].AggregateId < o.events[j].AggregateId })
+	return o
+}
+
+func TestRunOnceKeepsAggregateOrderAfterFailures(t *testing.T) {
+	outbox := newOutbox("a1", "a2", "a3", "b1", "b2")
+	publisher := &flakyPublisher{fail: map[string]bool{"a2": true}}
+	r := NewRelay(outbox, publisher, Config{})
+
+	n, err := r.RunOnce(context.Background())
+	if err == nil {
+		t.Fatal("expected the publish error")
+	}
+	// a3 waits for a2, b is not held up by a
+	if want := []string{"a1", "b1", "b2"}; n != 3 || !reflect.DeepEqual(publisher.published, want) {
+		t.Fatalf("first run: published %v (%d), want %v", publisher.published, n, want)
+	}
+
+	n, err = r.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a1", "b1", "b2", "a2", "a3"}; n != 2 || !reflect.DeepEqual(publisher.published, want) {
+		t.Fatalf("second run: published %v (%d), want %v", publisher.published, n, want)
+	}
+
+	if n, err := r.RunOnce(context.Background()); n != 0 || err != nil {
+		t.Errorf("third run: published %d, %v", n, err)
+	}
+}
+
+func TestRunOnceHonoursBatchSize(t *testing.T) {
+	outbox := newOutbox("a1", "a2", "a3")
+	publisher := &flakyPublisher{}
+	r := NewRelay(outbox, publisher, Config{BatchSize: 2})
+	if n, err := r.RunOnce(context.Background()); n != 2 || err != nil {
+		t.Fatalf("published %d, %v", n, err)
+	}
+	if n, err := r.RunOnce(context.Background()); n != 1 || err != nil {
+		t.Fatalf("published %d, %v", n, err)
+	}
+}
@@ -0,0 +1,78 @@
+package relay
+
+import (
+	"log"
+	"sync"
+
+	stan "github.com/nats-io/stan.go"
+	"github.com/pkg/errors"
+)
+
+// STANPublisher publishes over one NATS Streaming connection, which is
+// opened on first use and opened again after it was lost
+type STANPublisher struct {
+	clusterID string
+	clientID  string
+	options   []stan.Option
+
+	mu   sync.Mutex
+	conn stan.Conn
+}
+
+func NewSTANPublisher(clusterID, clientID string, options ...stan.Option) *STANPublisher {
+	return &STANPublisher{clusterID: clusterID, clientID: clientID, options: options}
+}
+
+// Publish publishes data on channel and waits for the acknowledgement of
+// the server
+func (p *STANPublisher) Publish(channel string, data []byte) error {
+	conn, err := p.connection()
+	if err != nil {
+		return err
+	}
+	err = conn.Publish(channel, data)
+	if err == stan.ErrConnectionClosed || err == stan.ErrBadConnection {
+		p.drop(conn)
+	}
+	return err
+}
+
+func (p *STANPublisher) connection() (stan.Conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		return p.conn, nil
+	}
+	options := append(append([]stan.Option{}, p.options...), stan.SetConnectionLostHandler(func(lost stan.Conn, err error) {
+		log.Printf("Lost the connection to NATS Streaming: %v", err)
+		p.drop(lost)
+	}))
+	conn, err := stan.Connect(p.clusterID, p.clientID, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error on connecting to NATS Streaming")
+	}
+	p.conn = conn
+	return conn, nil
+}
+
+// drop forgets conn, unless it was already replaced
+func (p *STANPublisher) drop(conn stan.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == conn && conn != nil {
+		conn.Close()
+		p.conn = nil
+	}
+}
+
+// Close closes the connection, if any
+func (p *STANPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
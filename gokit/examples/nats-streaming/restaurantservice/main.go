@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 	"log"
 	"runtime"
-	"time"
 
 	stan "github.com/nats-io/stan.go"
 
 	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+	"github.com/shijuvar/gokit/examples/nats-streaming/store"
+	"github.com/shijuvar/gokit/examples/nats-streaming/subscriber"
 )
 
 const (
@@ -19,6 +20,11 @@ const (
 )
 
 func main() {
+	// The database records the events already handled
+	repo, err := store.Open("postgres", store.DefaultDSN)
+	if err != nil {
+		log.Fatal(err)
+	}
 	sc, err := stan.Connect(
 		clusterID,
 		clientID,
@@ -28,24 +34,26 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	// Subscribe with manual ack mode, and set AckWait to 60 seconds
-	aw, _ := time.ParseDuration("60s")
-	sc.Subscribe(channel, func(msg *stan.Msg) {
-		msg.Ack() // Manual ACK
+	// Subscribe with manual ack mode, acking once the message is handled
+	_, err = subscriber.Subscribe(sc, repo, subscriber.Config{
+		Channel:     channel,
+		DurableName: durableID,
+	}, func(tx store.Tx, event *pb.Event) error {
+		if event.EventType != store.OrderCreated {
+			return nil
+		}
 		order := pb.Order{}
 		// Unmarshal JSON that represents the Order data
-		err := json.Unmarshal(msg.Data, &order)
+		err := json.Unmarshal([]byte(event.EventData), &order)
 		if err != nil {
-			log.Print(err)
-			return
+			return err
 		}
 		// Handle the message
 		log.Printf("Subscribed message from clientID - %s for Order: %+v\n", clientID, order)
-
-	}, stan.DurableName(durableID),
-		stan.MaxInflight(25),
-		stan.SetManualAckMode(),
-		stan.AckWait(aw),
-	)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 	runtime.Goexit()
 }
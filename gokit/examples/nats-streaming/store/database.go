@@ -2,39 +2,57 @@ package store
 
 import (
 	"database/sql"
-	"log"
 
 	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
 )
 
-var db *sql.DB
+// DefaultDSN is the "ordersdb" database on the local CockroachDB cluster
+const DefaultDSN = "postgresql://shijuvar@localhost:26257/ordersdb?sslmode=disable"
 
-func init() {
-	var err error
-	// Connect to the "ordersdb" database
-	db, err = sql.Open("postgres", "postgresql://shijuvar@localhost:26257/ordersdb?sslmode=disable")
+// Open connects to a database, creates the tables if needed and returns
+// a Repository on it
+func Open(driverName, dsn string) (*Repository, error) {
+	db, err := sql.Open(driverName, dsn)
 	if err != nil {
-		log.Fatal("error connecting to the database: ", err)
+		return nil, errors.Wrap(err, "error connecting to the database")
 	}
+	if err := createTables(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	r, err := NewRepository(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return r, nil
 }
-func createTables() {
 
+var tables = []string{
 	// Create the "events" table.
-	if _, err := db.Exec(
-		"CREATE TABLE IF NOT EXISTS events (id string PRIMARY KEY, eventtype string, aggregateid string, aggregatetype string, eventdata string, channel string)"); err != nil {
-		log.Fatal(err)
-	}
-
+	"CREATE TABLE IF NOT EXISTS events (id string PRIMARY KEY, eventtype string, aggregateid string, aggregatetype string, eventdata string, channel string, sequence int, createdon int)",
+	// Index the stream of each aggregate; being unique, it also stops two
+	// writers from appending the same version of an aggregate.
+	"CREATE UNIQUE INDEX IF NOT EXISTS " + sequenceIndex + " ON events (aggregateid, sequence)",
+	// Create the "outbox" table, holding the events to be published on
+	// NATS Streaming until senton is set.
+	"CREATE TABLE IF NOT EXISTS outbox (eventid string PRIMARY KEY, eventtype string, aggregateid string, aggregatetype string, sequence int, channel string, eventdata string, createdon int, senton int)",
+	"CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (senton, aggregateid, sequence)",
+	// Create the "processedevents" table, recording the events each
+	// subscriber has handled so redelivered events are skipped.
+	"CREATE TABLE IF NOT EXISTS processedevents (consumer string, eventid string, processedon int, PRIMARY KEY (consumer, eventid))",
 	// Create the "orders" table.
-	if _, err := db.Exec(
-		"CREATE TABLE IF NOT EXISTS orders (id string PRIMARY KEY, customerid string, status string, createdon int, restaurantid string)"); err != nil {
-		log.Fatal(err)
-	}
+	"CREATE TABLE IF NOT EXISTS orders (id string PRIMARY KEY, customerid string, status string, createdon int, restaurantid string)",
+	// Create the "orderitems" table.
+	"CREATE TABLE IF NOT EXISTS orderitems (id serial PRIMARY KEY, orderid string, customerid string, code string, name string, unitprice float, quantity int)",
+}
 
-	// Create the "orders" table.
-	if _, err := db.Exec(
-		"CREATE TABLE IF NOT EXISTS orderitems (id serial PRIMARY KEY, orderid string, customerid string, code string, name string, unitprice float, quantity int)"); err != nil {
-		log.Fatal(err)
+func createTables(db *sql.DB) error {
+	for _, table := range tables {
+		if _, err := db.Exec(table); err != nil {
+			return errors.Wrap(err, "Error on creating tables")
+		}
 	}
-
+	return nil
 }
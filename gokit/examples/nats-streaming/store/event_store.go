@@ -1,28 +1,123 @@
 package store
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 
 	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
 )
 
-type EventStore struct{}
+// AnyVersion as Event.ExpectedVersion appends to an aggregate whatever its
+// current version is
+const AnyVersion = -1
 
+var (
+	// ErrVersionConflict is returned by CreateEvent when the aggregate is
+	// not at Event.ExpectedVersion, because another writer got there first
+	ErrVersionConflict = errors.New("Aggregate version conflict")
+	// ErrEventExists is returned by CreateEvent for an already stored EventId
+	ErrEventExists = errors.New("Event exists")
+)
+
+// uniqueViolation is the SQLSTATE of a violated unique constraint
+const uniqueViolation = "23505"
+
+// sequenceIndex is the unique index on the stream of each aggregate
+const sequenceIndex = "events_aggregate_sequence"
+
+// EventStore persists domain events as immutable logs
+type EventStore struct {
+	repo *Repository
+}
+
+func NewEventStore(repo *Repository) EventStore {
+	return EventStore{repo: repo}
+}
+
+// CreateEvent appends event to the stream of its aggregate if the
+// aggregate is at event.ExpectedVersion. The store assigns Sequence and
+// CreatedOn, which are set on event. An event with a Channel is queued in
+// the outbox in the same transaction, to be published by a relay.
 func (store EventStore) CreateEvent(event *pb.Event) error {
-	// Insert two rows into the "accounts" table.
-	sql := fmt.Sprintf("INSERT INTO events (id, eventtype, aggregateid, aggregatetype, eventdata, channel) VALUES ('%s','%s','%s','%s','%s','%s')", event.EventId, event.EventType, event.AggregateId, event.AggregateType, event.EventData, event.Channel)
-	log.Print(sql)
-	_, err := db.Exec(sql)
+	createdOn := time.Now().Unix()
+	var sequence int64
+	err := store.repo.InTx(context.Background(), func(tx Tx) error {
+		exists, err := tx.EventExists(event.EventId)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrEventExists
+		}
+		version, err := tx.AggregateVersion(event.AggregateId)
+		if err != nil {
+			return err
+		}
+		if event.ExpectedVersion != AnyVersion && event.ExpectedVersion != version {
+			return ErrVersionConflict
+		}
+		// The event is only updated once the transaction has committed
+		e := *event
+		e.Sequence = version + 1
+		e.CreatedOn = createdOn
+		if err := insertError(tx.InsertEvent(&e)); err != nil {
+			return err
+		}
+		if e.Channel != "" {
+			if err := tx.InsertOutbox(&e); err != nil {
+				return err
+			}
+		}
+		sequence = e.Sequence
+		return nil
+	})
+	if err == ErrVersionConflict || err == ErrEventExists {
+		return err
+	}
 	if err != nil {
 		return errors.Wrap(err, "Error on insert into events")
 	}
+	event.Sequence = sequence
+	event.CreatedOn = createdOn
 	return nil
 }
 
-func (store EventStore) GetEvents(filter *pb.EventFilter) []*pb.Event {
-	var events []*pb.Event
-	return events
+// insertError translates the unique violations of an insert into events.
+// The unique index on (aggregateid, sequence) rejects a concurrent append
+// of the same sequence; the primary key rejects a concurrent insert of the
+// same EventId, e.g. by two retries of one request.
+func insertError(err error) error {
+	pqErr, ok := err.(*pq.Error)
+	if !ok || pqErr.Code != uniqueViolation {
+		return err
+	}
+	if pqErr.Constraint == sequenceIndex {
+		return ErrVersionConflict
+	}
+	return ErrEventExists
+}
+
+// GetEvents returns the events matching filter, ordered by aggregate and
+// sequence
+func (store EventStore) GetEvents(filter *pb.EventFilter) ([]*pb.Event, error) {
+	events, err := store.repo.Events(context.Background(), filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error on querying events")
+	}
+	return events, nil
+}
+
+// LoadOrder rebuilds the current state of an Order from its events
+func (store EventStore) LoadOrder(orderID string) (pb.Order, error) {
+	events, err := store.GetEvents(&pb.EventFilter{AggregateId: orderID})
+	if err != nil {
+		return pb.Order{}, err
+	}
+	if len(events) == 0 {
+		return pb.Order{}, ErrAggregateNotFound
+	}
+	return ReplayOrder(events)
 }
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestInsertError(t *testing.T) {
+	notNull := &pq.Error{Code: "23502", Constraint: sequenceIndex}
+	other := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"no error", nil, nil},
+		{"sequence taken", &pq.Error{Code: uniqueViolation, Constraint: sequenceIndex}, ErrVersionConflict},
+		{"event id taken", &pq.Error{Code: uniqueViolation, Constraint: "primary"}, ErrEventExists},
+		{"other violation", notNull, notNull},
+		{"other error", other, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := insertError(tt.err); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+)
+
+const (
+	// OrderAggregate is the AggregateType of Order events
+	OrderAggregate = "order"
+	// OrderCreated starts the stream of an Order; its EventData is the
+	// Order as JSON
+	OrderCreated = "OrderCreated"
+)
+
+var ErrAggregateNotFound = errors.New("Aggregate not found")
+
+// ReplayOrder folds the event stream of one Order, ordered by sequence,
+// into its current state. The stream must start with OrderCreated; the
+// EventData of every later event holds the Order fields it changes as
+// JSON and is applied over the state so far.
+func ReplayOrder(events []*pb.Event) (pb.Order, error) {
+	var order pb.Order
+	for i, e := range events {
+		if e.AggregateId != events[0].AggregateId {
+			return pb.Order{}, errors.Errorf("event %s belongs to aggregate %s, not %s", e.EventId, e.AggregateId, events[0].AggregateId)
+		}
+		if e.Sequence != int64(i+1) {
+			return pb.Order{}, errors.Errorf("event %s has sequence %d, expected %d", e.EventId, e.Sequence, i+1)
+		}
+		if (i == 0) != (e.EventType == OrderCreated) {
+			return pb.Order{}, errors.Errorf("unexpected %s event at sequence %d", e.EventType, e.Sequence)
+		}
+		if err := json.Unmarshal([]byte(e.EventData), &order); err != nil {
+			return pb.Order{}, errors.Wrapf(err, "Error on applying event %s", e.EventId)
+		}
+	}
+	if len(events) > 0 {
+		// The id of the stream wins over whatever the data says
+		order.OrderId = events[0].AggregateId
+	}
+	return order, nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+)
+
+func orderEvent(seq int64, eventType, data string) *pb.Event {
+	return &pb.Event{
+		EventId:       fmt.Sprintf("%s-%d", eventType, seq),
+		EventType:     eventType,
+		AggregateId:   "order-1",
+		AggregateType: OrderAggregate,
+		EventData:     data,
+		Sequence:      seq,
+	}
+}
+
+func TestReplayOrder(t *testing.T) {
+	events := []*pb.Event{
+		orderEvent(1, OrderCreated, `{"order_id":"order-1","customer_id":"c1","status":"Pending","order_items":[{"code":"p1","quantity":2}]}`),
+		orderEvent(2, "OrderApproved", `{"status":"Approved","restaurant_id":"r1"}`),
+	}
+	order, err := ReplayOrder(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderId != "order-1" || order.CustomerId != "c1" || order.Status != "Approved" || order.RestaurantId != "r1" {
+		t.Errorf("got %+v", order)
+	}
+	if len(order.OrderItems) != 1 || order.OrderItems[0].Quantity != 2 {
+		t.Errorf("order items: got %+v", order.OrderItems)
+	}
+}
+
+func TestReplayOrderRejectsBadStreams(t *testing.T) {
+	created := orderEvent(1, OrderCreated, `{"status":"Pending"}`)
+	other := orderEvent(2, OrderCreated, `{}`)
+	other.AggregateId = "order-2"
+	tests := map[string][]*pb.Event{
+		"gap":             {created, orderEvent(3, "OrderApproved", `{}`)},
+		"not created":     {orderEvent(1, "OrderApproved", `{}`)},
+		"created twice":   {created, orderEvent(2, OrderCreated, `{}`)},
+		"other aggregate": {created, other},
+		"bad data":        {created, orderEvent(2, "OrderApproved", `{`)},
+	}
+	for name, events := range tests {
+		if _, err := ReplayOrder(events); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+	"github.com/pkg/errors"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+)
+
+// statements are the SQL statements of Repository, prepared once. Every
+// value goes through a placeholder.
+var statements = map[string]string{
+	"eventExists":      "SELECT EXISTS (SELECT 1 FROM events WHERE id = $1)",
+	"aggregateVersion": "SELECT COALESCE(MAX(sequence), 0) FROM events WHERE aggregateid = $1",
+	"insertEvent":      "INSERT INTO events (id, eventtype, aggregateid, aggregatetype, eventdata, channel, sequence, createdon) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+	"insertOutbox":     "INSERT INTO outbox (eventid, eventtype, aggregateid, aggregatetype, sequence, channel, eventdata, createdon) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+	"pendingOutbox":    "SELECT eventid, eventtype, aggregateid, aggregatetype, sequence, channel, eventdata, createdon FROM outbox WHERE senton IS NULL ORDER BY aggregateid, sequence LIMIT $1",
+	"markSent":         "UPDATE outbox SET senton = $1 WHERE eventid = $2",
+	"processed":        "SELECT EXISTS (SELECT 1 FROM processedevents WHERE consumer = $1 AND eventid = $2)",
+	"markProcessed":    "INSERT INTO processedevents (consumer, eventid, processedon) VALUES ($1, $2, $3)",
+	"insertOrder":      "INSERT INTO orders (id, customerid, status, createdon, restaurantid) VALUES ($1, $2, $3, $4, $5)",
+	"insertOrderItem":  "INSERT INTO orderitems (orderid, customerid, code, name, unitprice, quantity) VALUES ($1, $2, $3, $4, $5, $6)",
+}
+
+// Repository gives typed access to the tables of the store
+type Repository struct {
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// NewRepository prepares the statements of the store on db, whose tables
+// must exist
+func NewRepository(db *sql.DB) (*Repository, error) {
+	r := &Repository{db: db, stmts: make(map[string]*sql.Stmt, len(statements))}
+	for name, query := range statements {
+		stmt, err := db.Prepare(query)
+		if err != nil {
+			r.Close()
+			return nil, errors.Wrapf(err, "Error on preparing %s", name)
+		}
+		r.stmts[name] = stmt
+	}
+	return r, nil
+}
+
+// Close releases the prepared statements and closes the database
+func (r *Repository) Close() error {
+	for _, stmt := range r.stmts {
+		stmt.Close()
+	}
+	return r.db.Close()
+}
+
+// Tx is a transaction on a Repository
+type Tx struct {
+	tx    *sql.Tx
+	stmts map[string]*sql.Stmt
+}
+
+// InTx runs fn in a transaction, which is retried as a whole when the
+// database asks for it
+func (r *Repository) InTx(ctx context.Context, fn func(Tx) error) error {
+	return crdb.ExecuteTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		return fn(Tx{tx: tx, stmts: r.stmts})
+	})
+}
+
+func (t Tx) stmt(name string) *sql.Stmt {
+	return t.tx.Stmt(t.stmts[name])
+}
+
+// EventExists reports whether an event with id is stored
+func (t Tx) EventExists(id string) (bool, error) {
+	var exists bool
+	err := t.stmt("eventExists").QueryRow(id).Scan(&exists)
+	return exists, err
+}
+
+// AggregateVersion returns the sequence of the last event of an
+// aggregate, 0 if it has none
+func (t Tx) AggregateVersion(aggregateID string) (int64, error) {
+	var version int64
+	err := t.stmt("aggregateVersion").QueryRow(aggregateID).Scan(&version)
+	return version, err
+}
+
+// InsertEvent stores e with its Sequence and CreatedOn
+func (t Tx) InsertEvent(e *pb.Event) error {
+	_, err := t.stmt("insertEvent").Exec(e.EventId, e.EventType, e.AggregateId, e.AggregateType,
+		e.EventData, e.Channel, e.Sequence, e.CreatedOn)
+	return err
+}
+
+// InsertOutbox queues e to be published on its Channel
+func (t Tx) InsertOutbox(e *pb.Event) error {
+	_, err := t.stmt("insertOutbox").Exec(e.EventId, e.EventType, e.AggregateId, e.AggregateType,
+		e.Sequence, e.Channel, e.EventData, e.CreatedOn)
+	return err
+}
+
+// PendingOutbox returns up to limit events which are not published yet,
+// by aggregate and in sequence order. Whatever the limit cuts off, the
+// events of an aggregate come without gaps from its first pending one.
+func (r *Repository) PendingOutbox(ctx context.Context, limit int) ([]*pb.Event, error) {
+	rows, err := r.stmts["pendingOutbox"].QueryContext(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*pb.Event
+	for rows.Next() {
+		var e pb.Event
+		err := rows.Scan(&e.EventId, &e.EventType, &e.AggregateId, &e.AggregateType, &e.Sequence, &e.Channel, &e.EventData, &e.CreatedOn)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// MarkSent records that the outbox event with eventID was published
+func (r *Repository) MarkSent(ctx context.Context, eventID string, sentOn int64) error {
+	_, err := r.stmts["markSent"].ExecContext(ctx, sentOn, eventID)
+	return err
+}
+
+// ProcessOnce runs fn in a transaction which records that consumer
+// processed the event with eventID, so the changes of fn are made once
+// per event. It returns false without running fn if the event was
+// processed before.
+func (r *Repository) ProcessOnce(ctx context.Context, consumer, eventID string, fn func(Tx) error) (bool, error) {
+	var processed bool
+	err := r.InTx(ctx, func(tx Tx) error {
+		processed = false
+		var seen bool
+		err := tx.stmt("processed").QueryRow(consumer, eventID).Scan(&seen)
+		if err != nil || seen {
+			return err
+		}
+		// Should another delivery of the event commit first, this insert
+		// fails on the primary key and the transaction is rolled back
+		if _, err := tx.stmt("markProcessed").Exec(consumer, eventID, time.Now().Unix()); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		processed = true
+		return nil
+	})
+	return processed, err
+}
+
+// InsertOrder stores order without its items
+func (t Tx) InsertOrder(order *pb.Order) error {
+	_, err := t.stmt("insertOrder").Exec(order.OrderId, order.CustomerId, order.Status,
+		order.CreatedOn, order.RestaurantId)
+	return err
+}
+
+// InsertOrderItem stores an item of order, with the customer denormalized
+// into the row
+func (t Tx) InsertOrderItem(order *pb.Order, item *pb.Order_OrderItem) error {
+	_, err := t.stmt("insertOrderItem").Exec(order.OrderId, order.CustomerId, item.Code, item.Name,
+		item.UnitPrice, item.Quantity)
+	return err
+}
+
+// Events returns the events matching filter, ordered by aggregate and
+// sequence. The conditions depend on the filter, so the query is built
+// per call, with the values still bound as parameters.
+func (r *Repository) Events(ctx context.Context, filter *pb.EventFilter) ([]*pb.Event, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.EventId != "" {
+		where("id = $%d", filter.EventId)
+	}
+	if filter.AggregateId != "" {
+		where("aggregateid = $%d", filter.AggregateId)
+	}
+	if filter.EventType != "" {
+		where("eventtype = $%d", filter.EventType)
+	}
+	if filter.FromSequence > 0 {
+		where("sequence >= $%d", filter.FromSequence)
+	}
+	if filter.ToSequence > 0 {
+		where("sequence <= $%d", filter.ToSequence)
+	}
+	query := "SELECT id, eventtype, aggregateid, aggregatetype, eventdata, channel, sequence, createdon FROM events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY aggregateid, sequence"
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := []*pb.Event{}
+	for rows.Next() {
+		var e pb.Event
+		err := rows.Scan(&e.EventId, &e.EventType, &e.AggregateId, &e.AggregateType, &e.EventData, &e.Channel, &e.Sequence, &e.CreatedOn)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,219 @@
+//go:build cgo
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+)
+
+// openTestRepository opens a Repository on an embedded SQLite database;
+// go-sqlite3 needs cgo, hence the build constraint of this file
+func openTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	repo, err := Open("sqlite3", filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestCreateEventStoresArbitraryStrings(t *testing.T) {
+	events := NewEventStore(openTestRepository(t))
+	n := 0
+	property := func(id, eventType, aggregateID, aggregateType, data, channel string) bool {
+		n++
+		in := &pb.Event{
+			// Prefixed, so generated ids don't collide
+			EventId:         fmt.Sprintf("%d%s", n, id),
+			EventType:       eventType,
+			AggregateId:     aggregateID,
+			AggregateType:   aggregateType,
+			EventData:       data,
+			Channel:         channel,
+			ExpectedVersion: AnyVersion,
+		}
+		if err := events.CreateEvent(in); err != nil {
+			t.Log(err)
+			return false
+		}
+		out, err := events.GetEvents(&pb.EventFilter{EventId: in.EventId})
+		if err != nil || len(out) != 1 {
+			t.Log(out, err)
+			return false
+		}
+		in.ExpectedVersion = 0
+		return reflect.DeepEqual(in, out[0])
+	}
+	if !property("'", "x'); DROP TABLE events; --", `"`, "\\", `{"name":"O'Brien"}`, "") {
+		t.Error("quotes were not stored verbatim")
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateEventChecksExpectedVersion(t *testing.T) {
+	events := NewEventStore(openTestRepository(t))
+	event := func(id string, expected int64) *pb.Event {
+		return &pb.Event{EventId: id, AggregateId: "order-1", ExpectedVersion: expected}
+	}
+	if err := events.CreateEvent(event("e1", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := events.CreateEvent(event("e2", 0)); err != ErrVersionConflict {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	if err := events.CreateEvent(event("e1", 1)); err != ErrEventExists {
+		t.Fatalf("expected ErrEventExists, got %v", err)
+	}
+	e3 := event("e3", 1)
+	if err := events.CreateEvent(e3); err != nil {
+		t.Fatal(err)
+	}
+	if e3.Sequence != 2 {
+		t.Errorf("expected sequence 2, got %d", e3.Sequence)
+	}
+	out, err := events.GetEvents(&pb.EventFilter{AggregateId: "order-1", FromSequence: 2})
+	if err != nil || len(out) != 1 || out[0].EventId != "e3" {
+		t.Errorf("got %v, %v", out, err)
+	}
+}
+
+func TestCreateEventQueuesOutbox(t *testing.T) {
+	repo := openTestRepository(t)
+	events := NewEventStore(repo)
+	for _, e := range []*pb.Event{
+		{EventId: "e1", AggregateId: "order-2", Channel: "order-notification", EventData: "1", ExpectedVersion: AnyVersion},
+		{EventId: "e2", AggregateId: "order-1", Channel: "order-notification", EventData: "2", ExpectedVersion: AnyVersion},
+		{EventId: "e3", AggregateId: "order-2", EventData: "not published", ExpectedVersion: AnyVersion},
+		{EventId: "e4", AggregateId: "order-2", Channel: "order-notification", EventData: "4", ExpectedVersion: AnyVersion},
+	} {
+		if err := events.CreateEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	pending, err := repo.PendingOutbox(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range pending {
+		ids = append(ids, e.EventId)
+	}
+	if want := []string{"e2", "e1", "e4"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("pending %v, want %v", ids, want)
+	}
+	if err := repo.MarkSent(ctx, "e2", 1); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := repo.PendingOutbox(ctx, 1); len(pending) != 1 || pending[0].EventId != "e1" {
+		t.Errorf("pending after e2 was sent: %v", pending)
+	}
+}
+
+func TestSyncOrderQueryModelStoresArbitraryValues(t *testing.T) {
+	repo := openTestRepository(t)
+	queries := NewQueryStore(repo)
+	n := 0
+	property := func(id, customerID, status, restaurantID string, createdOn int64, items []pb.Order_OrderItem) bool {
+		n++
+		order := pb.Order{
+			OrderId:      fmt.Sprintf("%d%s", n, id),
+			CustomerId:   customerID,
+			Status:       status,
+			CreatedOn:    createdOn,
+			RestaurantId: restaurantID,
+		}
+		for k := range items {
+			order.OrderItems = append(order.OrderItems, &items[k])
+		}
+		if err := queries.SyncOrderQueryModel(order); err != nil {
+			t.Log(err)
+			return false
+		}
+		got, err := readOrder(repo, order.OrderId)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return reflect.DeepEqual(order, got)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProcessOnceSyncsAnOrderOnce(t *testing.T) {
+	repo := openTestRepository(t)
+	ctx := context.Background()
+	order := &pb.Order{OrderId: "order-1", CustomerId: "c1", OrderItems: []*pb.Order_OrderItem{{Code: "p1", Quantity: 1}}}
+	sync := func(tx Tx) error { return CreateOrderQueryModel(tx, order) }
+
+	failed := errors.New("handler failed")
+	if _, err := repo.ProcessOnce(ctx, "query", "e1", func(tx Tx) error {
+		if err := sync(tx); err != nil {
+			return err
+		}
+		return failed
+	}); err != failed {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+	if _, err := readOrder(repo, "order-1"); err != sql.ErrNoRows {
+		t.Fatalf("failed handler was not rolled back: %v", err)
+	}
+
+	for i, want := range []bool{true, false} {
+		processed, err := repo.ProcessOnce(ctx, "query", "e1", sync)
+		if err != nil || processed != want {
+			t.Fatalf("delivery %d: processed %v, %v", i+1, processed, err)
+		}
+	}
+	got, err := readOrder(repo, "order-1")
+	if err != nil || len(got.OrderItems) != 1 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	// Another consumer processes the event on its own
+	if processed, err := repo.ProcessOnce(ctx, "restaurant", "e1", func(Tx) error { return nil }); !processed || err != nil {
+		t.Errorf("other consumer: processed %v, %v", processed, err)
+	}
+}
+
+// readOrder reads an order and its items back from the query model
+func readOrder(repo *Repository, id string) (pb.Order, error) {
+	var order pb.Order
+	err := repo.db.QueryRow("SELECT id, customerid, status, createdon, restaurantid FROM orders WHERE id = $1", id).
+		Scan(&order.OrderId, &order.CustomerId, &order.Status, &order.CreatedOn, &order.RestaurantId)
+	if err != nil {
+		return order, err
+	}
+	rows, err := repo.db.Query("SELECT customerid, code, name, unitprice, quantity FROM orderitems WHERE orderid = $1 ORDER BY rowid", id)
+	if err != nil {
+		return order, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item pb.Order_OrderItem
+		var customerID string
+		if err := rows.Scan(&customerID, &item.Code, &item.Name, &item.UnitPrice, &item.Quantity); err != nil {
+			return order, err
+		}
+		if customerID != order.CustomerId {
+			return order, fmt.Errorf("item of order %q has customer %q", id, customerID)
+		}
+		order.OrderItems = append(order.OrderItems, &item)
+	}
+	return order, rows.Err()
+}
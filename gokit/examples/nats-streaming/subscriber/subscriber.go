@@ -0,0 +1,183 @@
+// Package subscriber handles the events published by the relay of the
+// event store. A message is acknowledged only once it was handled, failed
+// handlers are retried with backoff, messages which keep failing are moved
+// to a dead-letter channel, and redelivered events are handled only once.
+package subscriber
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	stan "github.com/nats-io/stan.go"
+	"github.com/pkg/errors"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+	"github.com/shijuvar/gokit/examples/nats-streaming/store"
+)
+
+// Handler handles an event. Its changes to the store are made within tx,
+// which also records the event as processed.
+type Handler func(tx store.Tx, event *pb.Event) error
+
+// Processor records the events a consumer processed; store.Repository
+// implements it
+type Processor interface {
+	ProcessOnce(ctx context.Context, consumer, eventID string, fn func(store.Tx) error) (bool, error)
+}
+
+// Config describes a subscription. Zero values are replaced by defaults.
+type Config struct {
+	Channel string
+	// QueueGroup, if set, shares the messages among the subscribers of
+	// the group
+	QueueGroup  string
+	DurableName string
+	// Consumer names the subscriber in the processed events; subscribers
+	// of a queue group must share it (default QueueGroup, or DurableName)
+	Consumer string
+	// DeadLetterChannel receives the messages which could not be handled
+	// (default Channel + "-dead-letter")
+	DeadLetterChannel string
+	// MaxAttempts is the number of times a handler is run for a message
+	// (default 5)
+	MaxAttempts int
+	// Backoff is the wait after the first failure, doubling up to
+	// MaxBackoff (default 200ms and 5s)
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// AckWait is how long the server waits for an ack before redelivering;
+	// it should exceed the time taken by all attempts (default 60s)
+	AckWait     time.Duration
+	MaxInflight int // default 25
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Consumer == "" {
+		cfg.Consumer = cfg.QueueGroup
+	}
+	if cfg.Consumer == "" {
+		cfg.Consumer = cfg.DurableName
+	}
+	if cfg.DeadLetterChannel == "" {
+		cfg.DeadLetterChannel = cfg.Channel + "-dead-letter"
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 200 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = cfg.Backoff
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = 60 * time.Second
+	}
+	if cfg.MaxInflight <= 0 {
+		cfg.MaxInflight = 25
+	}
+	return cfg
+}
+
+// Subscriber is a subscription running a Handler for every event
+type Subscriber struct {
+	config    Config
+	processor Processor
+	handler   Handler
+	// publish sends a message to the dead-letter channel
+	publish func(channel string, data []byte) error
+	sub     stan.Subscription
+}
+
+// Subscribe subscribes to cfg.Channel on conn, in manual ack mode
+func Subscribe(conn stan.Conn, processor Processor, cfg Config, handler Handler) (*Subscriber, error) {
+	s := newSubscriber(conn.Publish, processor, cfg, handler)
+	if s.config.Consumer == "" {
+		return nil, errors.New("subscriber: Consumer, QueueGroup or DurableName is required")
+	}
+	options := []stan.SubscriptionOption{
+		stan.DurableName(s.config.DurableName),
+		stan.SetManualAckMode(),
+		stan.AckWait(s.config.AckWait),
+		stan.MaxInflight(s.config.MaxInflight),
+	}
+	var err error
+	if s.config.QueueGroup != "" {
+		s.sub, err = conn.QueueSubscribe(s.config.Channel, s.config.QueueGroup, s.onMessage, options...)
+	} else {
+		s.sub, err = conn.Subscribe(s.config.Channel, s.onMessage, options...)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error on subscribing to %s", s.config.Channel)
+	}
+	return s, nil
+}
+
+func newSubscriber(publish func(string, []byte) error, processor Processor, cfg Config, handler Handler) *Subscriber {
+	return &Subscriber{
+		config:    cfg.withDefaults(),
+		processor: processor,
+		handler:   handler,
+		publish:   publish,
+	}
+}
+
+// Close closes the subscription; a durable subscription resumes where it
+// left off when subscribing again
+func (s *Subscriber) Close() error {
+	return s.sub.Close()
+}
+
+func (s *Subscriber) onMessage(msg *stan.Msg) {
+	if s.process(msg.Data) {
+		msg.Ack()
+	}
+}
+
+// process handles a message and reports whether it may be acknowledged:
+// it was handled, or handled before, or moved to the dead-letter channel.
+// Otherwise the server redelivers it after AckWait.
+func (s *Subscriber) process(data []byte) bool {
+	var event pb.Event
+	if err := proto.Unmarshal(data, &event); err != nil || event.EventId == "" {
+		// Retrying does not make it readable
+		log.Printf("Unreadable message on %s: %v", s.config.Channel, err)
+		return s.deadLetter(data)
+	}
+	backoff := s.config.Backoff
+	for attempt := 1; ; attempt++ {
+		processed, err := s.processor.ProcessOnce(context.Background(), s.config.Consumer, event.EventId,
+			func(tx store.Tx) error { return s.handler(tx, &event) })
+		if err == nil {
+			if !processed {
+				log.Printf("Skipped event %s, already processed by %s", event.EventId, s.config.Consumer)
+			}
+			return true
+		}
+		log.Printf("Error while handling event %s (attempt %d of %d): %v", event.EventId, attempt, s.config.MaxAttempts, err)
+		if attempt == s.config.MaxAttempts {
+			return s.deadLetter(data)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+	}
+}
+
+// deadLetter moves a message to the dead-letter channel and reports
+// whether it succeeded
+func (s *Subscriber) deadLetter(data []byte) bool {
+	if err := s.publish(s.config.DeadLetterChannel, data); err != nil {
+		log.Printf("Error while publishing on %s: %v", s.config.DeadLetterChannel, err)
+		return false
+	}
+	log.Printf("Moved a message from %s to %s", s.config.Channel, s.config.DeadLetterChannel)
+	return true
+}
@@ -0,0 +1,119 @@
+//go:build cgo
+
+package subscriber
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/shijuvar/gokit/examples/nats-streaming/pb"
+	"github.com/shijuvar/gokit/examples/nats-streaming/store"
+)
+
+type deadLetters struct {
+	channels []string
+	err      error
+}
+
+func (d *deadLetters) publish(channel string, data []byte) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.channels = append(d.channels, channel)
+	return nil
+}
+
+// newTestSubscriber runs handler on a store on an embedded SQLite database;
+// go-sqlite3 needs cgo, hence the build constraint of this file
+func newTestSubscriber(t *testing.T, handler Handler) (*Subscriber, *deadLetters) {
+	t.Helper()
+	repo, err := store.Open("sqlite3", filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	dead := &deadLetters{}
+	cfg := Config{Channel: "order-notification", QueueGroup: "group", MaxAttempts: 3, Backoff: time.Millisecond}
+	return newSubscriber(dead.publish, repo, cfg, handler), dead
+}
+
+func message(t *testing.T, eventID string) []byte {
+	data, err := proto.Marshal(&pb.Event{EventId: eventID, EventType: "OrderCreated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRedeliveredEventIsHandledOnce(t *testing.T) {
+	calls := 0
+	s, dead := newTestSubscriber(t, func(tx store.Tx, event *pb.Event) error {
+		calls++
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		if !s.process(message(t, "e1")) {
+			t.Fatalf("delivery %d was not acked", i+1)
+		}
+	}
+	if calls != 1 || len(dead.channels) != 0 {
+		t.Errorf("handler ran %d times, %d dead letters", calls, len(dead.channels))
+	}
+}
+
+func TestFailingEventIsRetriedThenDeadLettered(t *testing.T) {
+	calls := 0
+	s, dead := newTestSubscriber(t, func(tx store.Tx, event *pb.Event) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	if !s.process(message(t, "e1")) || calls != 3 || len(dead.channels) != 0 {
+		t.Fatalf("third attempt should succeed: %d calls, %d dead letters", calls, len(dead.channels))
+	}
+
+	calls = 0
+	s.handler = func(tx store.Tx, event *pb.Event) error {
+		calls++
+		return errors.New("permanent")
+	}
+	if !s.process(message(t, "e2")) {
+		t.Fatal("dead-lettered message was not acked")
+	}
+	if calls != 3 || len(dead.channels) != 1 || dead.channels[0] != "order-notification-dead-letter" {
+		t.Fatalf("%d calls, dead letters on %v", calls, dead.channels)
+	}
+	// A failed event is not recorded as processed
+	calls = 0
+	s.handler = func(tx store.Tx, event *pb.Event) error {
+		calls++
+		return nil
+	}
+	if !s.process(message(t, "e2")) || calls != 1 {
+		t.Fatalf("redelivery: acked after %d calls", calls)
+	}
+}
+
+func TestMessageIsNotAckedWhenDeadLetteringFails(t *testing.T) {
+	s, dead := newTestSubscriber(t, func(tx store.Tx, event *pb.Event) error {
+		return errors.New("permanent")
+	})
+	dead.err = errors.New("no connection")
+	if s.process(message(t, "e1")) {
+		t.Error("message was acked")
+	}
+	if s.process([]byte("not an event")) {
+		t.Error("unreadable message was acked")
+	}
+	dead.err = nil
+	if !s.process([]byte("not an event")) || len(dead.channels) != 1 {
+		t.Errorf("unreadable message: dead letters on %v", dead.channels)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"flag"
+	stdlog "log"
 
 	"github.com/shijuvar/gokit/log"
 )
@@ -12,20 +13,23 @@ func main() {
 	logLevel := flag.Int("loglevel", 0, "an integer value (0-4)")
 	flag.Parse()
 	// Calling the SetLogLevel with the command-line argument
-	log.SetLogLevel(log.Level(*logLevel), "logs.txt")
-	log.Trace.Println("Main started")
+	if err := log.SetLogLevel(log.Level(*logLevel), "logs.txt"); err != nil {
+		stdlog.Fatal(err)
+	}
+	log.Trace("Main started")
 	loop()
 	err := errors.New("Sample Error")
-	log.Error.Println(err.Error())
-	log.Trace.Println("Main completed")
+	log.Error("loop failed", "error", err)
+	log.Trace("Main completed")
 }
 
 // A simple function for the logging demo
 func loop() {
-	log.Trace.Println("Loop started")
+	logger := log.Default().With("func", "loop")
+	logger.Trace("Loop started")
 	for i := 0; i < 10; i++ {
-		log.Info.Println("Counter value is:", i)
+		logger.Info("Counter value", "counter", i)
 	}
-	log.Warning.Println("The counter variable is not being used")
-	log.Trace.Println("Loop completed")
+	logger.Warning("The counter variable is not being used")
+	logger.Trace("Loop completed")
 }
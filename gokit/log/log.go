@@ -1,10 +1,20 @@
+// Package log provides a leveled, structured logger which writes
+// key/value records through pluggable sinks.
 package log
 
 import (
+	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
+	stdlog "log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 const (
@@ -23,66 +33,264 @@ const (
 // Level holds the log level.
 type Level int
 
-// Package level variables, which are pointer to log.Logger.
-var (
-	Trace   *log.Logger
-	Info    *log.Logger
-	Warning *log.Logger
-	Error   *log.Logger
-)
+// String returns the upper-case name of the level.
+func (l Level) String() string {
+	switch l {
+	case UNSPECIFIED:
+		return "UNSPECIFIED"
+	case TRACE:
+		return "TRACE"
+	case INFO:
+		return "INFO"
+	case WARNING:
+		return "WARNING"
+	case ERROR:
+		return "ERROR"
+	default:
+		return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// Field is a single key/value pair attached to a log record.
+type Field struct {
+	Key   string
+	Value interface{}
+}
 
-// initLog initializes log.Logger objects
-func initLog(
-	traceHandle io.Writer,
-	infoHandle io.Writer,
-	warningHandle io.Writer,
-	errorHandle io.Writer,
-	isFlag bool) {
-
-	// Flags for defines the logging properties, to log.New
-	flag := 0
-	if isFlag {
-		flag = log.Ldate | log.Ltime | log.Lshortfile
+// Record is a single log entry handed over to a Sink.
+type Record struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	// Caller is the "file:line" of the logging call site, if known.
+	Caller string
+	Fields []Field
+}
+
+// Logger writes leveled, structured records to a Sink.
+// A Logger is safe for concurrent use by multiple goroutines.
+type Logger struct {
+	sink   Sink
+	level  *atomic.Int32
+	fields []Field
+}
+
+// New creates a Logger which writes records at or above level to sink.
+func New(sink Sink, level Level) *Logger {
+	l := &Logger{
+		sink:  sink,
+		level: new(atomic.Int32),
 	}
+	l.level.Store(int32(level))
+	return l
+}
 
-	// Create log.Logger objects.
-	Trace = log.New(traceHandle, "TRACE: ", flag)
-	Info = log.New(infoHandle, "INFO: ", flag)
-	Warning = log.New(warningHandle, "WARNING: ", flag)
-	Error = log.New(errorHandle, "ERROR: ", flag)
+// With returns a child Logger which adds the given key/value pairs
+// to every record. The child shares its level with the parent.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	if len(keyvals) == 0 {
+		return l
+	}
+	fields := make([]Field, 0, len(l.fields)+len(keyvals)/2)
+	fields = append(fields, l.fields...)
+	fields = append(fields, toFields(keyvals)...)
+	return &Logger{
+		sink:   l.sink,
+		level:  l.level,
+		fields: fields,
+	}
+}
 
+// SetLevel changes the level of the Logger and all loggers derived
+// from it with With. It is safe to call while other goroutines log.
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int32(level))
 }
 
-// SetLogLevel sets the logging level preference
-func SetLogLevel(level Level, logFile string) {
+// Level returns the current level of the Logger.
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
+}
 
-	// Creates os.*File, which has implemented io.Writer intreface
-	f, err := os.OpenFile(logFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("Error opening log file: %s", err.Error())
+// Enabled reports whether a record at the given level would be written.
+func (l *Logger) Enabled(level Level) bool {
+	min := l.Level()
+	return min != UNSPECIFIED && level >= min
+}
+
+// Trace logs msg with key/value pairs at TRACE level.
+func (l *Logger) Trace(msg string, keyvals ...interface{}) {
+	l.log(TRACE, 2, msg, keyvals)
+}
+
+// Info logs msg with key/value pairs at INFO level.
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.log(INFO, 2, msg, keyvals)
+}
+
+// Warning logs msg with key/value pairs at WARNING level.
+func (l *Logger) Warning(msg string, keyvals ...interface{}) {
+	l.log(WARNING, 2, msg, keyvals)
+}
+
+// Error logs msg with key/value pairs at ERROR level.
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.log(ERROR, 2, msg, keyvals)
+}
+
+// Close closes the underlying Sink if it holds any resources.
+func (l *Logger) Close() error {
+	if c, ok := l.sink.(io.Closer); ok {
+		return c.Close()
 	}
+	return nil
+}
 
-	// Calls function initLog by specifying log level preference.
-	switch level {
-	case TRACE:
-		initLog(f, f, f, f, true)
-		return
+// StdLogger returns a standard library *log.Logger which writes each
+// line as a record at the given level, e.g. for http.Server.ErrorLog.
+func (l *Logger) StdLogger(level Level) *stdlog.Logger {
+	return stdlog.New(&stdWriter{logger: l, level: level}, "", 0)
+}
 
-	case INFO:
-		initLog(ioutil.Discard, f, f, f, true)
+func (l *Logger) log(level Level, skip int, msg string, keyvals []interface{}) {
+	if !l.Enabled(level) {
 		return
+	}
+	r := Record{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Caller:  caller(skip),
+	}
+	r.Fields = make([]Field, 0, len(l.fields)+len(keyvals)/2)
+	r.Fields = append(r.Fields, l.fields...)
+	r.Fields = append(r.Fields, toFields(keyvals)...)
+	if err := l.sink.Write(r); err != nil {
+		fmt.Fprintf(os.Stderr, "log: sink write failed: %v\n", err)
+	}
+}
 
-	case WARNING:
-		initLog(ioutil.Discard, ioutil.Discard, f, f, true)
-		return
-	case ERROR:
-		initLog(ioutil.Discard, ioutil.Discard, ioutil.Discard, f, true)
-		return
+// stdWriter adapts a Logger to io.Writer for the standard library logger.
+type stdWriter struct {
+	logger *Logger
+	level  Level
+}
 
-	default:
-		initLog(ioutil.Discard, ioutil.Discard, ioutil.Discard, ioutil.Discard, false)
-		f.Close()
-		return
+func (w *stdWriter) Write(p []byte) (int, error) {
+	// skip: log -> Write -> (*stdlog.Logger).output -> Print* -> caller
+	w.logger.log(w.level, 4, strings.TrimSuffix(string(p), "\n"), nil)
+	return len(p), nil
+}
+
+// toFields converts alternating key/value pairs into fields. A key
+// without a value is kept with the value "(MISSING)".
+func toFields(keyvals []interface{}) []Field {
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+	}
+	return fields
+}
+
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	return filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+var (
+	mu      sync.Mutex
+	std     atomic.Pointer[Logger]
+	current *RotatingFile
+)
+
+func init() {
+	std.Store(New(NewTextSink(os.Stderr), INFO))
+}
+
+// Default returns the package level Logger used by Trace, Info,
+// Warning and Error.
+func Default() *Logger {
+	return std.Load()
+}
+
+// SetDefault replaces the package level Logger.
+func SetDefault(l *Logger) {
+	std.Store(l)
+}
+
+// Trace logs msg with key/value pairs at TRACE level on the default Logger.
+func Trace(msg string, keyvals ...interface{}) {
+	Default().log(TRACE, 2, msg, keyvals)
+}
+
+// Info logs msg with key/value pairs at INFO level on the default Logger.
+func Info(msg string, keyvals ...interface{}) {
+	Default().log(INFO, 2, msg, keyvals)
+}
+
+// Warning logs msg with key/value pairs at WARNING level on the default Logger.
+func Warning(msg string, keyvals ...interface{}) {
+	Default().log(WARNING, 2, msg, keyvals)
+}
+
+// Error logs msg with key/value pairs at ERROR level on the default Logger.
+func Error(msg string, keyvals ...interface{}) {
+	Default().log(ERROR, 2, msg, keyvals)
+}
+
+// SetLogLevel sets the logging level preference and points the default
+// Logger at logFile as plain text, rotated with DefaultRotateConfig.
+// With UNSPECIFIED nothing is logged and no file is opened.
+func SetLogLevel(level Level, logFile string) error {
+	return SetLogFile(level, logFile, DefaultRotateConfig)
+}
+
+// SetLogFile is like SetLogLevel with explicit rotation settings. The
+// file is reopened when the process receives SIGHUP.
+func SetLogFile(level Level, logFile string, cfg RotateConfig) error {
+	if level == UNSPECIFIED {
+		swapDefault(New(NewTextSink(io.Discard), UNSPECIFIED), nil)
+		return nil
+	}
+	f, err := NewRotatingFile(logFile, cfg)
+	if err != nil {
+		return err
+	}
+	f.ReopenOnSignal(syscall.SIGHUP)
+	swapDefault(New(NewTextSink(f), level), f)
+	return nil
+}
+
+// Sync flushes the log file opened by SetLogLevel, if any. Call it
+// before the program exits.
+func Sync() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return nil
+	}
+	return current.Sync()
+}
 
+// swapDefault installs l as the default Logger and closes the file
+// opened by a previous SetLogLevel call.
+func swapDefault(l *Logger, f *RotatingFile) {
+	mu.Lock()
+	defer mu.Unlock()
+	SetDefault(l)
+	if current != nil {
+		current.Close()
 	}
+	current = f
 }
@@ -0,0 +1,198 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memSink keeps the records written to it
+type memSink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *memSink) Write(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *memSink) all() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.records...)
+}
+
+func TestLevelFiltering(t *testing.T) {
+	sink := &memSink{}
+	l := New(sink, WARNING)
+	l.Trace("trace")
+	l.Info("info")
+	l.Warning("warning")
+	l.Error("error")
+	l.SetLevel(UNSPECIFIED)
+	l.Error("discarded")
+
+	records := sink.all()
+	if len(records) != 2 || records[0].Message != "warning" || records[1].Message != "error" {
+		t.Fatalf("got %+v", records)
+	}
+	if records[0].Level != WARNING || records[1].Level != ERROR {
+		t.Errorf("levels = %v, %v", records[0].Level, records[1].Level)
+	}
+}
+
+func TestSetLevelWhileLogging(t *testing.T) {
+	sink := &memSink{}
+	l := New(sink, ERROR)
+	child := l.With("worker", true)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				child.Info("info")
+				child.Error("error")
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 500; j++ {
+			if j%2 == 0 {
+				l.SetLevel(INFO)
+			} else {
+				l.SetLevel(ERROR)
+			}
+		}
+	}()
+	wg.Wait()
+
+	errorCount := 0
+	for _, r := range sink.all() {
+		if r.Level < INFO {
+			t.Fatalf("record below INFO written: %+v", r)
+		}
+		if r.Level == ERROR {
+			errorCount++
+		}
+	}
+	// ERROR is enabled at both levels, so none is lost
+	if errorCount != 4*500 {
+		t.Errorf("got %d error records, want %d", errorCount, 4*500)
+	}
+
+	// The child shares the level of its parent
+	l.SetLevel(ERROR)
+	if child.Enabled(INFO) || child.Level() != ERROR {
+		t.Errorf("child level = %v after parent SetLevel(ERROR)", child.Level())
+	}
+}
+
+func TestWithInheritsFields(t *testing.T) {
+	sink := &memSink{}
+	parent := New(sink, INFO).With("service", "api")
+	child := parent.With("request", 7)
+	child.Info("handled", "status", 200)
+	parent.Info("started")
+
+	records := sink.all()
+	if len(records) != 2 {
+		t.Fatalf("got %d records", len(records))
+	}
+	want := []Field{{"service", "api"}, {"request", 7}, {"status", 200}}
+	if got := records[0].Fields; len(got) != len(want) {
+		t.Fatalf("child fields = %v, want %v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("child field %d = %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+	// Fields added to the child do not leak into the parent
+	if got := records[1].Fields; len(got) != 1 || got[0] != (Field{"service", "api"}) {
+		t.Errorf("parent fields = %v", got)
+	}
+	if !strings.HasPrefix(records[0].Caller, "log_test.go:") {
+		t.Errorf("caller = %q", records[0].Caller)
+	}
+}
+
+func TestTextSinkFormat(t *testing.T) {
+	var buf bytes.Buffer
+	r := Record{
+		Time:    time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC),
+		Level:   INFO,
+		Message: "server started",
+		Caller:  "main.go:12",
+		Fields: []Field{
+			{"addr", ":8080"},
+			{"name", "two words"},
+			{"empty", ""},
+			{"err", errors.New("boom")},
+			{"odd", "(MISSING)"},
+		},
+	}
+	if err := NewTextSink(&buf).Write(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `INFO: 2009/11/10 23:00:00 main.go:12: server started addr=:8080 name="two words" empty="" err=boom odd=(MISSING)` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %q\nwant %q", buf.String(), want)
+	}
+}
+
+func TestJSONSinkFormat(t *testing.T) {
+	var buf bytes.Buffer
+	r := Record{
+		Time:    time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC),
+		Level:   ERROR,
+		Message: `say "hi"`,
+		Caller:  "main.go:12",
+		Fields:  []Field{{"count", 3}, {"err", errors.New("boom")}},
+	}
+	if err := NewJSONSink(&buf).Write(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2009-11-10T23:00:00.000Z","level":"ERROR","caller":"main.go:12","msg":"say \"hi\"","count":3,"err":"boom"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %q\nwant %q", buf.String(), want)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Errorf("not valid JSON: %v", err)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	l := New(NewSlogSink(h), TRACE)
+	l.Trace("dropped by the handler")
+	l.With("user", "alice").Warning("slow", "ms", 250)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %q", err, buf.String())
+	}
+	if got["level"] != "WARN" || got["msg"] != "slow" || got["user"] != "alice" || got["ms"] != float64(250) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestToFieldsMissingValue(t *testing.T) {
+	fields := toFields([]interface{}{"a", 1, 2})
+	if len(fields) != 2 || fields[1] != (Field{"2", "(MISSING)"}) {
+		t.Errorf("got %v", fields)
+	}
+}
@@ -0,0 +1,378 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is used in the names of rotated files. It sorts
+// lexicographically in time order.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig controls when a RotatingFile rolls over and how many
+// rotated files are kept.
+type RotateConfig struct {
+	// MaxSize rotates the file before a write would take it past
+	// MaxSize bytes. Zero disables size based rotation.
+	MaxSize int64
+	// Interval rotates the file when a write falls into a new Interval
+	// period, e.g. 24*time.Hour rotates once per day. Zero disables time
+	// based rotation.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep. Zero keeps all.
+	MaxBackups int
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// DefaultRotateConfig is used by SetLogLevel.
+var DefaultRotateConfig = RotateConfig{
+	MaxSize:    100 << 20, // 100 MB
+	Interval:   24 * time.Hour,
+	MaxBackups: 7,
+	Compress:   true,
+}
+
+// RotatingFile is an io.WriteCloser which writes to a file and rotates
+// it by size and time. Rotated files are renamed to
+// name-<timestamp>.ext in the same directory.
+// A RotatingFile is safe for concurrent use by multiple goroutines.
+type RotatingFile struct {
+	path string
+	cfg  RotateConfig
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+
+	// millMu serializes compressing and pruning of rotated files.
+	millMu sync.Mutex
+	wg     sync.WaitGroup
+
+	sigCh chan os.Signal
+	done  chan struct{}
+}
+
+// NewRotatingFile opens or creates the file at path for appending.
+func NewRotatingFile(path string, cfg RotateConfig) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, cfg: cfg}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes p to the current file, rotating it first if needed.
+// After Close it returns os.ErrClosed.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.shouldRotate(int64(len(p)), time.Now()) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it and opens a new one.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	return r.rotate()
+}
+
+// Reopen closes and reopens the file at the configured path. It is
+// meant for external tools such as logrotate which move the file away.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+	return r.open()
+}
+
+// ReopenOnSignal calls Reopen each time one of the given signals,
+// typically SIGHUP, is received, until the file is closed.
+func (r *RotatingFile) ReopenOnSignal(sig ...os.Signal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sigCh != nil {
+		return
+	}
+	r.sigCh = make(chan os.Signal, 1)
+	r.done = make(chan struct{})
+	signal.Notify(r.sigCh, sig...)
+	go func(sigCh chan os.Signal, done chan struct{}) {
+		for {
+			select {
+			case <-sigCh:
+				if err := r.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "log: reopening %s: %v\n", r.path, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}(r.sigCh, r.done)
+}
+
+// Close stops signal handling, waits for pending compression and
+// closes the current file. Later writes fail with os.ErrClosed.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	if r.sigCh != nil {
+		signal.Stop(r.sigCh)
+		close(r.done)
+		r.sigCh = nil
+	}
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+// Sync commits the current file to stable storage and waits for
+// pending compression.
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		err = r.file.Sync()
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("log: creating log directory: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("log: opening log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("log: opening log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	if r.size > 0 {
+		// An existing file belongs to the period of its last write.
+		r.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (r *RotatingFile) shouldRotate(n int64, now time.Time) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.cfg.MaxSize > 0 && r.size+n > r.cfg.MaxSize {
+		return true
+	}
+	if r.cfg.Interval > 0 && !now.Truncate(r.cfg.Interval).Equal(r.openedAt.Truncate(r.cfg.Interval)) {
+		return true
+	}
+	return false
+}
+
+// rotate must be called with r.mu held.
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("log: closing log file: %w", err)
+		}
+		r.file = nil
+	}
+	backup := r.backupName(time.Now())
+	if err := os.Rename(r.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("log: rotating log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.mill(backup)
+	}()
+	return nil
+}
+
+func (r *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := r.nameParts()
+	name := filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat)+ext)
+	// Keep rotations within the same millisecond from overwriting each other.
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err := os.Stat(name + ".gz"); os.IsNotExist(err) {
+				return name
+			}
+		}
+		name = filepath.Join(dir, fmt.Sprintf("%s%s.%d%s", prefix, t.UTC().Format(backupTimeFormat), i, ext))
+	}
+}
+
+// nameParts splits the path into directory, backup prefix and extension,
+// e.g. "logs/app.txt" into "logs", "app-" and ".txt".
+func (r *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(r.path)
+	base := filepath.Base(r.path)
+	ext = filepath.Ext(base)
+	prefix = strings.TrimSuffix(base, ext) + "-"
+	return dir, prefix, ext
+}
+
+// mill compresses the newly rotated file and removes old backups.
+func (r *RotatingFile) mill(backup string) {
+	r.millMu.Lock()
+	defer r.millMu.Unlock()
+
+	if r.cfg.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "log: compressing %s: %v\n", backup, err)
+		}
+	}
+	if r.cfg.MaxBackups > 0 {
+		if err := r.removeOldBackups(); err != nil {
+			fmt.Fprintf(os.Stderr, "log: removing old log files: %v\n", err)
+		}
+	}
+}
+
+// backups returns the rotated files of r, oldest first. Files rotated
+// within the same millisecond are ordered by their collision counter.
+func (r *RotatingFile) backups() ([]string, error) {
+	dir, prefix, ext := r.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		name  string
+		stamp string
+		n     int
+	}
+	var found []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, prefix)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)]); err != nil {
+			continue
+		}
+		b := backup{name: filepath.Join(dir, name), stamp: stamp[:len(backupTimeFormat)]}
+		if counter := stamp[len(backupTimeFormat):]; counter != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(counter, "."))
+			if err != nil || !strings.HasPrefix(counter, ".") || n < 1 {
+				continue
+			}
+			b.n = n
+		}
+		found = append(found, b)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].stamp != found[j].stamp {
+			return found[i].stamp < found[j].stamp
+		}
+		return found[i].n < found[j].n
+	})
+	names := make([]string, len(found))
+	for i, b := range found {
+		names[i] = b.name
+	}
+	return names, nil
+}
+
+func (r *RotatingFile) removeOldBackups() error {
+	names, err := r.backups()
+	if err != nil {
+		return err
+	}
+	for len(names) > r.cfg.MaxBackups {
+		if err := os.Remove(names[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// compressFile gzips name into name.gz and removes name.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,193 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Sink receives the records written by a Logger.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	Write(r Record) error
+}
+
+// TextSink writes records as single lines of text, e.g.
+//
+//	INFO: 2009/11/10 23:00:00 main.go:12: server started addr=:8080
+type TextSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTextSink creates a TextSink which writes to w.
+func NewTextSink(w io.Writer) *TextSink {
+	return &TextSink{w: w}
+}
+
+// Write formats r as a line of text.
+func (s *TextSink) Write(r Record) error {
+	var buf bytes.Buffer
+	buf.WriteString(r.Level.String())
+	buf.WriteString(": ")
+	buf.WriteString(r.Time.Format("2006/01/02 15:04:05"))
+	buf.WriteByte(' ')
+	if r.Caller != "" {
+		buf.WriteString(r.Caller)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(r.Message)
+	for _, f := range r.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		buf.WriteString(textValue(f.Value))
+	}
+	buf.WriteByte('\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (s *TextSink) Close() error {
+	return closeWriter(s.w)
+}
+
+// textValue formats v, quoting it when it contains spaces or quotes.
+func textValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	for _, c := range s {
+		if c <= ' ' || c == '"' || c == '=' {
+			return strconv.Quote(s)
+		}
+	}
+	if s == "" {
+		return `""`
+	}
+	return s
+}
+
+// JSONSink writes records as one JSON object per line with the keys
+// "time", "level", "caller" and "msg" followed by the record fields.
+type JSONSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONSink creates a JSONSink which writes to w.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w}
+}
+
+// Write encodes r as a JSON object.
+func (s *JSONSink) Write(r Record) error {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONField(&buf, "time", r.Time.Format("2006-01-02T15:04:05.000Z07:00"), true)
+	writeJSONField(&buf, "level", r.Level.String(), false)
+	if r.Caller != "" {
+		writeJSONField(&buf, "caller", r.Caller, false)
+	}
+	writeJSONField(&buf, "msg", r.Message, false)
+	for _, f := range r.Fields {
+		v := f.Value
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		writeJSONField(&buf, f.Key, v, false)
+	}
+	buf.WriteString("}\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (s *JSONSink) Close() error {
+	return closeWriter(s.w)
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}, first bool) {
+	if !first {
+		buf.WriteByte(',')
+	}
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(v)
+}
+
+// closeWriter closes w if it is an io.Closer, leaving the standard
+// output streams open.
+func closeWriter(w io.Writer) error {
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SlogSink forwards records to a log/slog Handler.
+type SlogSink struct {
+	h slog.Handler
+}
+
+// NewSlogSink creates a SlogSink which writes to h.
+func NewSlogSink(h slog.Handler) *SlogSink {
+	return &SlogSink{h: h}
+}
+
+// Write converts r into a slog.Record and passes it to the handler.
+func (s *SlogSink) Write(r Record) error {
+	ctx := context.Background()
+	level := slogLevel(r.Level)
+	if !s.h.Enabled(ctx, level) {
+		return nil
+	}
+	sr := slog.NewRecord(r.Time, level, r.Message, 0)
+	for _, f := range r.Fields {
+		sr.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	return s.h.Handle(ctx, sr)
+}
+
+// slogLevel maps a Level onto the closest slog.Level.
+func slogLevel(l Level) slog.Level {
+	switch l {
+	case TRACE:
+		return slog.LevelDebug
+	case WARNING:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCheckTimeout bounds each readiness check
+const DefaultCheckTimeout = 2 * time.Second
+
+// A Check reports whether a dependency, e.g. the database, is usable
+type Check func(ctx context.Context) error
+
+// Health serves liveness and readiness probes. The service is ready when
+// it has been marked ready and every registered Check passes.
+type Health struct {
+	ready   atomic.Bool
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+// NewHealth returns a Health which is not ready yet
+func NewHealth() *Health {
+	return &Health{
+		timeout: DefaultCheckTimeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// AddCheck registers a readiness check under name
+func (h *Health) AddCheck(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// SetReady marks the service as ready or not, e.g. while shutting down
+func (h *Health) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+type healthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// LivenessHandler responds 200 as long as the process serves requests,
+// e.g. on "/healthz"
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, healthStatus{Status: "ok"})
+	})
+}
+
+// ReadinessHandler responds 200 if the service is ready and 503 with the
+// failing checks otherwise, e.g. on "/readyz"
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, code := h.check(r.Context())
+		writeStatus(w, code, status)
+	})
+}
+
+func (h *Health) check(ctx context.Context) (healthStatus, int) {
+	if !h.ready.Load() {
+		return healthStatus{Status: "unavailable"}, http.StatusServiceUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	h.mu.RLock()
+	checks := make(map[string]Check, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	status := healthStatus{Status: "ok", Checks: make(map[string]string, len(checks))}
+	code := http.StatusOK
+	for name, check := range checks {
+		if err := check(ctx); err != nil {
+			status.Status = "unavailable"
+			status.Checks[name] = err.Error()
+			code = http.StatusServiceUnavailable
+			continue
+		}
+		status.Checks[name] = "ok"
+	}
+	return status, code
+}
+
+func writeStatus(w http.ResponseWriter, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,174 @@
+// Package server runs an http.Server until the process is asked to stop,
+// then drains in-flight requests and runs cleanup hooks in order.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shijuvar/gokit/log"
+)
+
+const (
+	// DefaultDrainTimeout is how long in-flight requests may take to finish
+	DefaultDrainTimeout = 15 * time.Second
+	// DefaultHookTimeout bounds the time spent in all cleanup hooks
+	DefaultHookTimeout = 10 * time.Second
+)
+
+// A Hook releases a resource on shutdown, e.g. closes a DB connection
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	fn   Hook
+}
+
+// Runner runs Server and shuts it down gracefully on SIGINT or SIGTERM
+type Runner struct {
+	Server *http.Server
+	// Listener, if set, is used instead of listening on Server.Addr
+	Listener net.Listener
+	// DrainTimeout is the deadline for Server.Shutdown; open connections
+	// are closed forcibly once it expires
+	DrainTimeout time.Duration
+	// HookTimeout is the deadline shared by all cleanup hooks
+	HookTimeout time.Duration
+	// Health, if set, reports not ready as soon as shutdown starts
+	Health *Health
+	Logger *log.Logger
+
+	hooks []namedHook
+}
+
+// NewRunner returns a Runner for srv with the default timeouts
+func NewRunner(srv *http.Server) *Runner {
+	return &Runner{
+		Server:       srv,
+		DrainTimeout: DefaultDrainTimeout,
+		HookTimeout:  DefaultHookTimeout,
+	}
+}
+
+// OnShutdown registers a cleanup hook. Hooks run in registration order
+// after the server has stopped accepting requests, so a later hook can
+// rely on the earlier ones having finished.
+func (r *Runner) OnShutdown(name string, fn Hook) {
+	r.hooks = append(r.hooks, namedHook{name: name, fn: fn})
+}
+
+// Run serves until SIGINT or SIGTERM is received, then shuts down.
+// SIGKILL cannot be caught, so it is not listed.
+func (r *Runner) Run() error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case sig := <-interrupt:
+			r.logger().Info("Got signal, shutting down", "signal", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return r.RunContext(ctx)
+}
+
+// RunContext serves until ctx is done or the server fails, then drains
+// the server and runs the cleanup hooks. The hooks run even if the
+// server could not start. It returns all errors encountered.
+func (r *Runner) RunContext(ctx context.Context) error {
+	logger := r.logger()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.serve()
+	}()
+	if r.Health != nil {
+		r.Health.SetReady(true)
+	}
+	logger.Info("The service is listening", "addr", r.addr())
+
+	var errs []error
+	select {
+	case err := <-errc:
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("server: %w", err))
+		}
+	case <-ctx.Done():
+	}
+
+	logger.Info("The service is shutting down...")
+	if r.Health != nil {
+		r.Health.SetReady(false)
+	}
+	if err := r.shutdown(); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, r.runHooks()...)
+	for _, err := range errs {
+		logger.Error("Shut down error", "error", err)
+	}
+	logger.Info("Shut down is done")
+	return errors.Join(errs...)
+}
+
+func (r *Runner) serve() error {
+	if r.Listener != nil {
+		return r.Server.Serve(r.Listener)
+	}
+	return r.Server.ListenAndServe()
+}
+
+// shutdown waits up to DrainTimeout for in-flight requests
+func (r *Runner) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), orDefault(r.DrainTimeout, DefaultDrainTimeout))
+	defer cancel()
+	if err := r.Server.Shutdown(ctx); err != nil {
+		r.Server.Close()
+		return fmt.Errorf("draining connections: %w", err)
+	}
+	return nil
+}
+
+func (r *Runner) runHooks() []error {
+	ctx, cancel := context.WithTimeout(context.Background(), orDefault(r.HookTimeout, DefaultHookTimeout))
+	defer cancel()
+	var errs []error
+	for _, h := range r.hooks {
+		if err := h.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errs
+}
+
+func (r *Runner) addr() string {
+	if r.Listener != nil {
+		return r.Listener.Addr().String()
+	}
+	return r.Server.Addr
+}
+
+func (r *Runner) logger() *log.Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return log.Default()
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shijuvar/gokit/log"
+)
+
+func newTestRunner(t *testing.T, h http.Handler) (*Runner, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRunner(&http.Server{Handler: h})
+	r.Listener = l
+	r.Logger = log.New(log.NewTextSink(io.Discard), log.ERROR)
+	return r, "http://" + l.Addr().String()
+}
+
+func TestRunner_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r, url := newTestRunner(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	}))
+
+	var order []string
+	r.OnShutdown("db", func(ctx context.Context) error {
+		order = append(order, "db")
+		return nil
+	})
+	r.OnShutdown("logs", func(ctx context.Context) error {
+		order = append(order, "logs")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- r.RunContext(ctx) }()
+
+	respc := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			respc <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		respc <- string(b)
+	}()
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if body := <-respc; body != "done" {
+		t.Errorf("in-flight request expected to complete, got: %q", body)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("RunContext: %v", err)
+	}
+	if strings.Join(order, ",") != "db,logs" {
+		t.Errorf("hooks expected to run in order db,logs, got: %v", order)
+	}
+}
+
+func TestRunner_DrainTimeout(t *testing.T) {
+	started := make(chan struct{})
+	r, url := newTestRunner(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-req.Context().Done()
+	}))
+	r.DrainTimeout = 50 * time.Millisecond
+	hookRan := false
+	r.OnShutdown("db", func(ctx context.Context) error {
+		hookRan = true
+		return errors.New("close failed")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- r.RunContext(ctx) }()
+	go http.Get(url)
+	<-started
+	cancel()
+
+	err := <-runErr
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected drain deadline error, got: %v", err)
+	}
+	if !hookRan || !strings.Contains(err.Error(), "db: close failed") {
+		t.Errorf("expected hook error to be reported, got: %v", err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	h := NewHealth()
+	var dbErr error
+	h.AddCheck("db", func(ctx context.Context) error { return dbErr })
+
+	probe := func(handler http.Handler) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w.Code
+	}
+	if code := probe(h.LivenessHandler()); code != http.StatusOK {
+		t.Errorf("liveness expected: %d, got: %d", http.StatusOK, code)
+	}
+	if code := probe(h.ReadinessHandler()); code != http.StatusServiceUnavailable {
+		t.Errorf("readiness before SetReady expected: %d, got: %d", http.StatusServiceUnavailable, code)
+	}
+	h.SetReady(true)
+	if code := probe(h.ReadinessHandler()); code != http.StatusOK {
+		t.Errorf("readiness expected: %d, got: %d", http.StatusOK, code)
+	}
+	dbErr = errors.New("connection refused")
+	if code := probe(h.ReadinessHandler()); code != http.StatusServiceUnavailable {
+		t.Errorf("readiness with failing check expected: %d, got: %d", http.StatusServiceUnavailable, code)
+	}
+}